@@ -2,11 +2,13 @@ package boneful
 
 import (
 	"bytes"
-	"io"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"text/template"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -70,17 +72,185 @@ func TestSaveDocs(t *testing.T) {
 		Writes("docid").
 		Returns(http.StatusBadRequest, "document is invalid", nil))
 
-	mux := s.Mux()
+	AssertDocsGolden(t, s, "testdata/sample.md")
+	AssertDocsGolden(t, s, "testdata/sample.json")
+}
+
+type handlerHolder struct{}
+
+func (h *handlerHolder) GetThing(rw http.ResponseWriter, req *http.Request) {
+}
+
+func TestOperationDerived(t *testing.T) {
+	h := &handlerHolder{}
+	anon := func(rw http.ResponseWriter, req *http.Request) {}
+
+	assert.Equal(t, "SampleHandler", NewRouteBuilder().To(SampleHandler).Build().Operation)
+	assert.Equal(t, "GetThing", NewRouteBuilder().To(h.GetThing).Build().Operation)
+	assert.Equal(t, "Explicit", NewRouteBuilder().To(h.GetThing).Operation("Explicit").Build().Operation)
 
-	req, _ := http.NewRequest("GET", "/md", nil)
+	name, anonymous := handlerName(anon)
+	assert.True(t, anonymous)
+	assert.Equal(t, "TestOperationDerived_func1", name)
+}
+
+func TestOperationUnique(t *testing.T) {
+	s := new(Service).Path("/test")
+	s.Route(s.GET("/a").To(SampleHandler))
+	assert.Panics(t, func() { s.Route(s.GET("/b").To(SampleHandler)) })
+	s.Route(s.GET("/b").To(SampleHandler).Operation("SampleHandlerB"))
+	assert.Len(t, s.Routes(), 2)
+
+	anon := func(rw http.ResponseWriter, req *http.Request) {}
+	s.Route(s.GET("/c").To(anon))
+	defer func() {
+		r := recover()
+		assert.Contains(t, r, "anonymous function")
+	}()
+	s.Route(s.GET("/d").To(anon))
+}
+
+func TestHandlerDocs(t *testing.T) {
+	docs, err := ParseHandlerDocs(".", "github.com/kentquirk/boneful", "")
+	assert.Nil(t, err)
+	hd, ok := docs["github.com/kentquirk/boneful.(*Service).GetDocMD"]
+	assert.True(t, ok)
+	assert.Equal(t, "GetDocMD is a handler for markdown documentation.", hd.Doc)
+	assert.Equal(t, "service.go", hd.File)
+
+	// a service built before the docs are registered, as in a package-level
+	// var initialized before the init function bonedoc writes
+	early := new(Service).Path("/")
+	early.Route(early.GET("/md").To(early.GetDocMD).Operation("Markdown"))
+	early.Route(early.GET("/explicit").To(SampleHandler).Doc("Explicit"))
+	assert.Equal(t, "", early.Routes()[0].Doc)
+	before := httptest.NewRecorder()
+	early.GetDocMD(before, httptest.NewRequest("GET", "/docs", nil))
+
+	docs["github.com/kentquirk/boneful.SampleHandler"] = HandlerDoc{Doc: "SampleHandler samples.\n\nIt does nothing."}
+	RegisterHandlerDocs(docs)
+	t.Cleanup(func() {
+		// so that later tests don't pick up this package's doc comments
+		handlerDocsMu.Lock()
+		defer handlerDocsMu.Unlock()
+		for k := range docs {
+			delete(handlerDocs, k)
+		}
+	})
+	assert.Equal(t, "GetDocMD is a handler for markdown documentation.", early.Routes()[0].Doc)
+	assert.Equal(t, "service.go", early.Routes()[0].Source.File)
+	assert.Equal(t, "Explicit", early.Routes()[1].Doc)
+	assert.Equal(t, "It does nothing.", early.Routes()[1].Notes)
+	after := httptest.NewRecorder()
+	early.GetDocMD(after, httptest.NewRequest("GET", "/docs", nil))
+	assert.NotContains(t, before.Body.String(), "GetDocMD is a handler")
+	assert.Contains(t, after.Body.String(), "GetDocMD is a handler")
+
+	s := new(Service).Path("/")
+	s.Route(s.GET("/md").To(s.GetDocMD))
+	r := s.Routes()[0]
+	assert.Equal(t, "GetDocMD is a handler for markdown documentation.", r.Doc)
+	assert.Equal(t, "service.go", r.Source.File)
+
+	s.Route(s.GET("/sample").To(SampleHandler))
+	assert.True(t, strings.HasSuffix(s.Routes()[1].Source.File, "boneful_test.go"))
+
+	s.SourceURL("", "https://example.com/{{.File}}#L{{.Line}}")
+	assert.Equal(t, "https://example.com/service.go#L"+strconv.Itoa(r.Source.Line), s.SourceLink(r))
+}
+
+func TestDocTemplate(t *testing.T) {
+	s := new(Service).Path("/").Doc("Templated")
+	s.Route(s.GET("/foo").To(SampleHandler).Doc("a | b").Returns(http.StatusNotFound, "", nil))
+
+	tmpl := template.Must(template.New("x").Funcs(TemplateFuncs()).Parse(
+		`{{.Documentation}}{{range .Routes}} {{slugify .Operation}} {{escape .Doc}}{{range .ResponseErrors}} {{statusText .Code}}{{end}}{{end}}`))
+	buf := &bytes.Buffer{}
+	s.DocTemplate(tmpl).GenerateDocumentation(buf)
+	assert.Equal(t, `Templated samplehandler a \| b Not Found`, buf.String())
+
+	fsys := fstest.MapFS{
+		"docs/api.md.tmpl": {Data: []byte(`{{template "title" .}}{{range .Routes}} {{code .Path}}{{end}}`)},
+		"docs/title.tmpl":  {Data: []byte(`{{define "title"}}# {{.Documentation}}{{end}}`)},
+	}
+	buf.Reset()
+	s.DocTemplateFS(fsys, "docs/api.md.tmpl", "docs/title.tmpl").GenerateDocumentation(buf)
+	assert.Equal(t, "# Templated `/foo`", buf.String())
+}
+
+func TestHTMLDocs(t *testing.T) {
+	s := new(Service).Path("/api").Doc("HTML <docs>")
+	s.Route(s.POST("/things/:id").To(SampleHandler).
+		Doc("Make a thing").
+		Param(PathParameter("id", "The id")).
+		Param(QueryParameter("q", "A query").DefaultValue("x")).
+		Consumes("application/json").
+		Reads(map[string]string{"name": "thing"}).
+		Returns(http.StatusConflict, "already exists", nil))
+	s.Route(s.GET("/widgets/#id^[0-9]+$").To(SampleHandler).Operation("Widget").
+		Param(PathParameter("id", "The id")).
+		Produces("application/json").
+		Writes([]widget{}).
+		Returns(http.StatusNotFound, "missing", node{}))
+
+	req, _ := http.NewRequest("GET", "/api/docs", nil)
 	w := httptest.NewRecorder()
-	mux.ServeHTTP(w, req)
+	s.Mux().ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
 
-	f, err := os.Create("SAMPLE_DOCS.md")
-	if err != nil {
-		panic("Couldn't create SAMPLE_DOCS.md")
-	}
-	io.Copy(f, w.Body)
-	f.Close()
+	body := w.Body.String()
+	assert.Contains(t, body, "HTML &lt;docs&gt;")
+	assert.Contains(t, body, `data-path="/api/things/{id}"`)
+	assert.Contains(t, body, `data-path="/api/widgets/{id}"`)
+	assert.Contains(t, body, `path.split("{" + el.name + "}")`)
+
+	// the schema is described by the fields of the models
+	assert.Contains(t, body, `<h4>Response (application/json): <code>[]</code><a href="#model-boneful-widget"><code>boneful.widget</code></a></h4>`)
+	assert.Contains(t, body, `<td>404</td><td>missing</td><td><a href="#model-boneful-node"><code>boneful.node</code></a></td>`)
+	assert.Contains(t, body, `<section class="model" id="model-boneful-node">`)
+	assert.Contains(t, body, `<tr><td><code>children</code></td><td><code>[]*</code><a href="#model-boneful-node"><code>boneful.node</code></a></td><td>optional</td>`)
+	assert.Contains(t, body, `<tr><td><code>name</code></td><td><code>string</code></td><td>required</td>`)
+	assert.Contains(t, body, `data-kind="Query" name="q" value="x"`)
+	assert.Contains(t, body, `&#34;name&#34;: &#34;thing&#34;`)
+	assert.NotContains(t, body, "<script src")
+	assert.NotContains(t, body, "<link")
+}
+
+func TestDesignFirst(t *testing.T) {
+	s := new(Service).Path("/api")
+	assert.Panics(t, func() { s.Route(s.GET("/later").Doc("Not yet")) })
+
+	s.DesignFirst(false)
+	s.Route(s.GET("/later").Doc("Not yet"))
+	s.Route(s.GET("/named").Operation("Named").Doc("Not yet either").
+		Produces("application/json").Writes(map[string]int{"n": 1}))
+	s.Route(s.GET("/now").To(SampleHandler).Doc("Implemented"))
+
+	routes := s.Routes()
+	assert.True(t, routes[0].Planned)
+	assert.Equal(t, "GET /api/later", routes[0].Operation)
+	assert.True(t, routes[1].Planned)
+	assert.False(t, routes[2].Planned)
+
+	req, _ := http.NewRequest("GET", "/api/named", nil)
+	w := httptest.NewRecorder()
+	s.Mux().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	s.DesignFirst(true)
+	w = httptest.NewRecorder()
+	s.Mux().ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"n":1}`, w.Body.String())
+
+	buf := &bytes.Buffer{}
+	s.GenerateDocumentation(buf)
+	assert.Contains(t, buf.String(), "* [Named](#named) (planned)")
+	assert.Contains(t, buf.String(), "> **Planned:**")
+	assert.Equal(t, 2, strings.Count(buf.String(), "> **Planned:**"))
+
+	doc := s.JSONDoc()
+	assert.True(t, doc.Routes[0].Planned)
+	assert.False(t, doc.Routes[2].Planned)
 }
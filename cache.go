@@ -0,0 +1,169 @@
+package boneful
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// renderedDoc is one documentation format, rendered and ready to serve.
+type renderedDoc struct {
+	contentType string
+	etag        string
+	body        []byte
+	gzipped     []byte
+	gzipETag    string // the gzipped body is a different representation
+	lastUsed    uint64
+}
+
+// maxCachedDocs limits the size of the cache, since with CurrentServer
+// the documentation is rendered for each server it's requested from.
+// When it is full, the least recently used documentation is dropped.
+const maxCachedDocs = 64
+
+// docCache holds rendered documentation by format (and by server, for
+// CurrentServer). It is emptied whenever anything that affects the
+// documentation changes.
+type docCache struct {
+	mu    sync.Mutex
+	docs  map[string]*renderedDoc
+	clock uint64 // counts uses, to find the least recently used
+	gen   uint64 // the generation of the renderer registry the docs are from
+	hdGen uint64 // and of the handler docs
+}
+
+func (c *docCache) invalidate() {
+	c.mu.Lock()
+	c.docs = nil
+	c.mu.Unlock()
+}
+
+// get returns the rendered form of s for the given renderer, rendering it
+// if it isn't already cached under key.
+func (c *docCache) get(s *Service, r DocRenderer, key string) (*renderedDoc, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen, hdGen := renderersGeneration(), handlerDocsGeneration(); gen != c.gen || hdGen != c.hdGen {
+		c.docs, c.gen, c.hdGen = nil, gen, hdGen
+	}
+	c.clock++
+	if d, ok := c.docs[key]; ok {
+		d.lastUsed = c.clock
+		return d, nil
+	}
+	buf := &bytes.Buffer{}
+	if err := r.Render(buf, s); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	tag := hex.EncodeToString(sum[:16])
+	d := &renderedDoc{
+		contentType: r.ContentType(),
+		etag:        `"` + tag + `"`,
+		gzipETag:    `"` + tag + `-gzip"`,
+		body:        buf.Bytes(),
+		lastUsed:    c.clock,
+	}
+	zbuf := &bytes.Buffer{}
+	zw := gzip.NewWriter(zbuf)
+	zw.Write(d.body)
+	if err := zw.Close(); err == nil {
+		d.gzipped = zbuf.Bytes()
+	}
+	if c.docs == nil {
+		c.docs = make(map[string]*renderedDoc)
+	}
+	if len(c.docs) >= maxCachedDocs {
+		oldest := ""
+		for k, each := range c.docs {
+			if oldest == "" || each.lastUsed < c.docs[oldest].lastUsed {
+				oldest = k
+			}
+		}
+		delete(c.docs, oldest)
+	}
+	c.docs[key] = d
+	return d, nil
+}
+
+// precompute renders every registered format, so that the first requests
+// for documentation don't pay for it.
+func (c *docCache) precompute(s *Service) {
+	if s.currentServer != "" {
+		return
+	}
+	for _, r := range DocRenderers() {
+		c.get(s, r, r.Format())
+	}
+}
+
+// serveDoc writes the cached documentation for the given renderer,
+// honoring If-None-Match and Accept-Encoding.
+func (s *Service) serveDoc(rw http.ResponseWriter, req *http.Request, r DocRenderer) {
+	h := rw.Header()
+	view, key := s, r.Format()
+	if s.currentServer != "" {
+		if current, ok := requestServer(req, s.currentServer, s.trustForwarded); ok {
+			view = s.withServers(append([]Server{current}, s.servers...))
+			key += " " + current.URL
+		}
+		if s.trustForwarded {
+			h.Add("Vary", "X-Forwarded-Host, X-Forwarded-Proto")
+		}
+	}
+	d, err := s.cache.get(view, r, key)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body, etag := d.body, d.etag
+	gzipped := d.gzipped != nil && acceptsGzip(req)
+	if gzipped {
+		body, etag = d.gzipped, d.gzipETag
+	}
+	h.Set("ETag", etag)
+	h.Add("Vary", "Accept-Encoding")
+	if etagMatches(req.Header.Get("If-None-Match"), etag) {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Type", d.contentType)
+	if gzipped {
+		h.Set("Content-Encoding", "gzip")
+	}
+	if req.Method == "HEAD" {
+		return
+	}
+	rw.Write(body)
+}
+
+// etagMatches implements the (weak) comparison used for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func acceptsGzip(req *http.Request) bool {
+	for _, enc := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if q := strings.Replace(p, " ", "", -1); q == "q=0" || q == "q=0.0" || q == "q=0.00" || q == "q=0.000" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,616 @@
+package boneful
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GoClientOptions controls the client generated by GenerateGoClient.
+type GoClientOptions struct {
+	// Package is the name of the generated package. Default "client".
+	Package string
+}
+
+// GenerateGoClient writes the source of a Go package that calls the
+// service: a Client type with one method per route, named after its
+// Operation. Path, query, header and form parameters become arguments
+// (optional ones are pointers, omitted when nil), request and response
+// bodies are typed from the schemas of the Reads and Writes samples, and
+// documented Returns codes come back as typed errors.
+func (s *Service) GenerateGoClient(w io.Writer, opts GoClientOptions) error {
+	return GenerateGoClient(w, s.JSONDoc(), opts)
+}
+
+// GenerateGoClient is like Service.GenerateGoClient, but works from JSON
+// documentation, so clients can be generated without the service's code.
+func GenerateGoClient(w io.Writer, doc *JSONDoc, opts GoClientOptions) error {
+	if opts.Package == "" {
+		opts.Package = "client"
+	}
+	g := &goClientGen{doc: doc, typeNames: make(map[string]string), errorNames: make(map[int]string),
+		used: make(map[string]bool)}
+	// everything the runtime declares, as package identifiers or as
+	// fields and methods of Client
+	for _, reserved := range []string{"Client", "NewClient", "APIError", "newError", "BaseURL", "HTTPClient", "Header", "do"} {
+		g.used[reserved] = true
+	}
+	g.nameErrors()
+	g.nameTypes()
+
+	methods := &strings.Builder{}
+	for _, r := range doc.Routes {
+		name := uniqueName(exportedName(r.Operation), g.used)
+		if name == "" {
+			name = uniqueName(exportedName(r.Method+" "+r.Path), g.used)
+		}
+		g.method(methods, name, r)
+	}
+
+	// the types are generated first, since they decide whether "time" is
+	// imported
+	types := &strings.Builder{}
+	g.errorTypes(types)
+	g.types(types)
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "// Code generated by boneful from the documentation of %s; DO NOT EDIT.\n\n", doc.Service.RootPath)
+	fmt.Fprintf(b, "// Package %s is a client for the %s API.\n", opts.Package, doc.Service.RootPath)
+	fmt.Fprintf(b, "package %s\n\n", opts.Package)
+	b.WriteString("import (\n\t\"bytes\"\n\t\"context\"\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"io\"\n\t\"net/http\"\n\t\"net/url\"\n\t\"strings\"\n")
+	if g.usesTime {
+		b.WriteString("\t\"time\"\n")
+	}
+	b.WriteString(")\n\n")
+	b.WriteString(goClientRuntime)
+	b.WriteString(types.String())
+	b.WriteString(methods.String())
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return fmt.Errorf("[boneful] generated client doesn't compile: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type goClientGen struct {
+	doc        *JSONDoc
+	typeNames  map[string]string // schema name to Go type name
+	errorNames map[int]string    // status code to error type name
+	used       map[string]bool   // every generated identifier, so none collide
+	usesTime   bool
+}
+
+// nameTypes gives each documented schema a Go name, qualifying it with its
+// package name if the plain names collide.
+func (g *goClientGen) nameTypes() {
+	byName := make(map[string][]string)
+	for key := range g.doc.Schemas {
+		byName[exportedName(schemaBaseName(key))] = append(byName[exportedName(schemaBaseName(key))], key)
+	}
+	names := make([]string, 0, len(byName))
+	for n := range byName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		keys := byName[n]
+		sort.Strings(keys)
+		for _, key := range keys {
+			name := n
+			if len(keys) > 1 {
+				name = exportedName(strings.Replace(key, ".", " ", -1))
+			}
+			g.typeNames[key] = uniqueName(name, g.used)
+		}
+	}
+}
+
+// schemaBaseName strips the package qualifier from a schema name.
+func schemaBaseName(key string) string {
+	if ix := strings.LastIndex(key, "."); ix >= 0 {
+		return key[ix+1:]
+	}
+	return key
+}
+
+// goType returns the Go type for a schema.
+func (g *goClientGen) goType(sc *Schema) string {
+	if sc == nil {
+		return "json.RawMessage"
+	}
+	ptr := ""
+	if sc.Nullable {
+		ptr = "*"
+	}
+	if sc.Ref != "" {
+		if name, ok := g.typeNames[sc.RefName()]; ok {
+			return ptr + name
+		}
+		return "json.RawMessage"
+	}
+	switch sc.Type {
+	case "string":
+		switch sc.Format {
+		case "date-time":
+			g.usesTime = true
+			return ptr + "time.Time"
+		case "byte":
+			return "[]byte"
+		}
+		return ptr + "string"
+	case "integer":
+		if sc.Format != "" && sc.Format != "uintptr" {
+			return ptr + sc.Format
+		}
+		return ptr + "int64"
+	case "number":
+		if sc.Format == "float32" {
+			return ptr + "float32"
+		}
+		return ptr + "float64"
+	case "boolean":
+		return ptr + "bool"
+	case "array":
+		return "[]" + g.goType(sc.Items)
+	case "object":
+		if sc.Properties != nil {
+			return ptr + g.structType(sc)
+		}
+		if sc.AdditionalProperties != nil {
+			return "map[string]" + g.goType(sc.AdditionalProperties)
+		}
+		return "map[string]interface{}"
+	}
+	return "json.RawMessage"
+}
+
+func (g *goClientGen) structType(sc *Schema) string {
+	props := make([]string, 0, len(sc.Properties))
+	for p := range sc.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+	required := make(map[string]bool)
+	for _, r := range sc.Required {
+		required[r] = true
+	}
+	b := &strings.Builder{}
+	b.WriteString("struct {\n")
+	used := make(map[string]bool)
+	for _, p := range props {
+		tag := p
+		if !required[p] {
+			tag += ",omitempty"
+		}
+		field := uniqueName(exportedName(p), used)
+		if field == "" {
+			field = uniqueName("Field", used)
+		}
+		fmt.Fprintf(b, "%s %s `json:%q`\n", field, g.goType(sc.Properties[p]), tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func (g *goClientGen) types(b *strings.Builder) {
+	keys := make([]string, 0, len(g.typeNames))
+	for k := range g.typeNames {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return g.typeNames[keys[i]] < g.typeNames[keys[j]] })
+	for _, k := range keys {
+		sc := g.doc.Schemas[k]
+		fmt.Fprintf(b, "// %s is the client's copy of %s.\n", g.typeNames[k], k)
+		fmt.Fprintf(b, "type %s %s\n\n", g.typeNames[k], g.goType(&Schema{Type: sc.Type, Format: sc.Format,
+			Properties: sc.Properties, Required: sc.Required, Items: sc.Items,
+			AdditionalProperties: sc.AdditionalProperties}))
+	}
+}
+
+// nameErrors names the error types for the documented status codes.
+func (g *goClientGen) nameErrors() {
+	var codes []int
+	for _, r := range g.doc.Routes {
+		for _, resp := range r.Responses {
+			if _, ok := g.errorNames[resp.Code]; !ok && !isSuccess(resp.Code) && errorTypeName(resp.Code) != "" {
+				g.errorNames[resp.Code] = ""
+				codes = append(codes, resp.Code)
+			}
+		}
+	}
+	sort.Ints(codes)
+	for _, c := range codes {
+		g.errorNames[c] = uniqueName(errorTypeName(c), g.used)
+	}
+}
+
+func (g *goClientGen) errorTypes(b *strings.Builder) {
+	codes := make([]int, 0, len(g.errorNames))
+	for c := range g.errorNames {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+	for _, c := range codes {
+		name := g.errorNames[c]
+		fmt.Fprintf(b, "// %s is returned for %d %s responses.\ntype %s struct{ *APIError }\n\n",
+			name, c, http.StatusText(c), name)
+	}
+	b.WriteString("func newError(code int, message string, body []byte) error {\n")
+	b.WriteString("e := &APIError{StatusCode: code, Message: message, Body: body}\n")
+	if len(codes) > 0 {
+		b.WriteString("switch code {\n")
+		for _, c := range codes {
+			fmt.Fprintf(b, "case %d:\nreturn %s{e}\n", c, g.errorNames[c])
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteString("return e\n}\n\n")
+}
+
+func isSuccess(code int) bool {
+	return code >= 200 && code < 300
+}
+
+// errorTypeName names the error type for a status code, like NotFoundError.
+func errorTypeName(code int) string {
+	text := http.StatusText(code)
+	if text == "" {
+		return ""
+	}
+	name := exportedName(text)
+	if !strings.HasSuffix(name, "Error") {
+		name += "Error"
+	}
+	return name
+}
+
+// goParamType maps a parameter's DataType to a Go type.
+func goParamType(dataType string) string {
+	switch strings.ToLower(dataType) {
+	case "int", "integer", "int32", "int64", "long":
+		return "int64"
+	case "bool", "boolean":
+		return "bool"
+	case "number", "float", "double", "float32", "float64":
+		return "float64"
+	}
+	return "string"
+}
+
+func isTextType(mimeTypes []string) bool {
+	for _, m := range mimeTypes {
+		if strings.HasPrefix(m, "text/") {
+			return true
+		}
+		if m == "application/json" {
+			return false
+		}
+	}
+	return false
+}
+
+func (g *goClientGen) method(b *strings.Builder, name string, r JSONRoute) {
+	type arg struct {
+		name, goType string
+		p            JSONParameter
+	}
+	used := map[string]bool{"ctx": true, "c": true, "out": true, "err": true, "query": true,
+		"header": true, "form": true, "path": true, "body": true, "contentType": true,
+		"reqBody": true, "errs": true, "data": true, "merr": true, "v": true}
+	var args []arg
+	var bodyArg *arg
+	params := r.Parameters
+	// path variables need arguments even if they weren't documented
+	for _, m := range pathVariable.FindAllStringSubmatch(r.Path, -1) {
+		declared := false
+		for _, p := range params {
+			declared = declared || (p.Kind == "path" && p.Name == m[1])
+		}
+		if !declared {
+			params = append([]JSONParameter{{Name: m[1], Kind: "path", Required: true}}, params...)
+		}
+	}
+	for _, p := range params {
+		if p.Kind == "body" {
+			bodyArg = &arg{name: "body", goType: g.goType(r.ReadSchema), p: p}
+			if isTextType(r.Consumes) {
+				bodyArg.goType = "string"
+			}
+			continue
+		}
+		t := goParamType(p.DataType)
+		if p.AllowMultiple {
+			t = "[]" + t
+		} else if !p.Required {
+			t = "*" + t
+		}
+		args = append(args, arg{name: uniqueName(unexportedName(p.Name), used), goType: t, p: p})
+	}
+
+	outType := ""
+	textOut := isTextType(r.Produces)
+	if len(r.WriteSample) > 0 || r.WriteSchema != nil {
+		outType = g.goType(r.WriteSchema)
+		if textOut {
+			outType = "string"
+		}
+	}
+
+	fmt.Fprintf(b, "// %s calls %s %s.\n", name, r.Method, r.Path)
+	if r.Doc != "" {
+		b.WriteString("//\n")
+		for _, line := range strings.Split(strings.TrimSpace(r.Doc), "\n") {
+			fmt.Fprintf(b, "// %s\n", line)
+		}
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(ctx context.Context", name)
+	for _, a := range args {
+		fmt.Fprintf(b, ", %s %s", a.name, a.goType)
+	}
+	if bodyArg != nil {
+		fmt.Fprintf(b, ", body %s", bodyArg.goType)
+	}
+	if outType != "" {
+		fmt.Fprintf(b, ") (%s, error) {\nvar out %s\n", outType, outType)
+	} else {
+		b.WriteString(") error {\n")
+	}
+
+	// the path, with its variables filled in
+	var parts []string
+	literal := ""
+	for _, seg := range strings.Split(strings.TrimPrefix(r.Path, "/"), "/") {
+		literal += "/"
+		v := ""
+		if m := pathVariable.FindStringSubmatch(seg); m != nil && strings.IndexAny(seg, ":#") == 0 {
+			v = m[1]
+		}
+		found := false
+		for _, a := range args {
+			if v != "" && a.p.Kind == "path" && a.p.Name == v {
+				parts = append(parts, fmt.Sprintf("%q", literal), "url.PathEscape("+g.format(a.name, a.goType)+")")
+				literal, found = "", true
+				break
+			}
+		}
+		if !found {
+			literal += seg
+		}
+	}
+	if literal != "" {
+		parts = append(parts, fmt.Sprintf("%q", literal))
+	}
+	b.WriteString("path := " + strings.Join(parts, " + "))
+	b.WriteString("\nquery := url.Values{}\nheader := http.Header{}\n")
+	hasForm := false
+	for _, a := range args {
+		hasForm = hasForm || a.p.Kind == "form"
+	}
+	if hasForm && bodyArg == nil {
+		b.WriteString("form := url.Values{}\n")
+	}
+	for _, a := range args {
+		var target string
+		switch a.p.Kind {
+		case "query":
+			target = "query.Add"
+		case "header":
+			target = "header.Add"
+		case "form":
+			if bodyArg != nil {
+				continue
+			}
+			target = "form.Add"
+		default:
+			continue
+		}
+		switch {
+		case strings.HasPrefix(a.goType, "[]"):
+			fmt.Fprintf(b, "for _, v := range %s {\n%s(%q, %s)\n}\n", a.name, target, a.p.Name, g.format("v", a.goType[2:]))
+		case strings.HasPrefix(a.goType, "*"):
+			fmt.Fprintf(b, "if %s != nil {\n%s(%q, %s)\n}\n", a.name, target, a.p.Name, g.format("*"+a.name, a.goType[1:]))
+		default:
+			fmt.Fprintf(b, "%s(%q, %s)\n", target, a.p.Name, g.format(a.name, a.goType))
+		}
+	}
+	b.WriteString("var reqBody io.Reader\ncontentType := \"\"\n")
+	switch {
+	case bodyArg != nil && bodyArg.goType == "string":
+		b.WriteString("reqBody = strings.NewReader(body)\n")
+		fmt.Fprintf(b, "contentType = %q\n", firstOr(r.Consumes, "text/plain"))
+	case bodyArg != nil:
+		b.WriteString("data, merr := json.Marshal(body)\nif merr != nil {\n")
+		if outType != "" {
+			b.WriteString("return out, merr\n}\n")
+		} else {
+			b.WriteString("return merr\n}\n")
+		}
+		b.WriteString("reqBody = bytes.NewReader(data)\ncontentType = \"application/json\"\n")
+	case hasForm:
+		b.WriteString("reqBody = strings.NewReader(form.Encode())\ncontentType = \"application/x-www-form-urlencoded\"\n")
+	}
+
+	b.WriteString("errs := map[int]string{")
+	for _, resp := range r.Responses {
+		fmt.Fprintf(b, "%d: %q, ", resp.Code, resp.Message)
+	}
+	b.WriteString("}\n")
+	outArg := "nil"
+	if outType != "" {
+		outArg = "&out"
+	}
+	fmt.Fprintf(b, "err := c.do(ctx, %q, path, query, header, reqBody, contentType, %s, %v, errs)\n", r.Method, outArg, textOut)
+	if outType != "" {
+		b.WriteString("return out, err\n}\n\n")
+	} else {
+		b.WriteString("return err\n}\n\n")
+	}
+}
+
+// format returns an expression that converts v (of type t) to a string.
+func (g *goClientGen) format(v, t string) string {
+	if t == "string" {
+		return v
+	}
+	return "fmt.Sprint(" + v + ")"
+}
+
+func firstOr(list []string, def string) string {
+	if len(list) > 0 {
+		return list[0]
+	}
+	return def
+}
+
+var nameWord = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// commonInitialisms are written in capitals, as golint would like.
+var commonInitialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "id": true, "json": true, "uri": true,
+	"url": true, "uuid": true, "xml": true, "ip": true, "sql": true, "tls": true,
+}
+
+// exportedName turns arbitrary text into an exported Go identifier:
+// "get widget (by id)" becomes "GetWidgetByID".
+func exportedName(s string) string {
+	b := &strings.Builder{}
+	for _, w := range nameWord.FindAllString(s, -1) {
+		if commonInitialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	name := b.String()
+	if name != "" && !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// unexportedName turns arbitrary text into an unexported Go identifier.
+func unexportedName(s string) string {
+	name := exportedName(s)
+	if name == "" {
+		return "arg"
+	}
+	words := nameWord.FindAllString(s, -1)
+	if len(words) > 0 && commonInitialisms[strings.ToLower(words[0])] {
+		name = strings.ToLower(words[0]) + name[len(words[0]):]
+	} else {
+		r := []rune(name)
+		name = string(unicode.ToLower(r[0])) + string(r[1:])
+	}
+	if token.Lookup(name).IsKeyword() {
+		name += "_"
+	}
+	return name
+}
+
+// uniqueName returns name, or name with a number added if it's in use,
+// and records the result as used.
+func uniqueName(name string, used map[string]bool) string {
+	if name == "" {
+		return ""
+	}
+	candidate := name
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s%d", name, n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+var goClientRuntime = `// Client calls the API. Its zero value is not usable; use NewClient.
+type Client struct {
+	// BaseURL is the scheme and host (and any path prefix) of the service.
+	BaseURL string
+
+	// HTTPClient makes the requests; http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+
+	// Header holds headers to add to every request, such as authorization.
+	Header http.Header
+}
+
+// NewClient returns a Client for the service at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), Header: make(http.Header)}
+}
+
+// APIError is returned when the service responds with an unsuccessful status.
+// Documented status codes are returned as the more specific error types
+// below, which embed it.
+type APIError struct {
+	StatusCode int
+	Message    string // the documented meaning of the status, if any
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Decode unmarshals the JSON body of the error response into v.
+func (e *APIError) Decode(v interface{}) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header,
+	body io.Reader, contentType string, out interface{}, textOut bool, errs map[int]string) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = append(req.Header[k], v...)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp.StatusCode, errs[resp.StatusCode], data)
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if s, ok := out.(*string); ok && textOut {
+		*s = string(data)
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+`
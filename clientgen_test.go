@@ -0,0 +1,70 @@
+package boneful
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	Created time.Time `json:"created"`
+}
+
+// notFoundError and client are named like identifiers the generator
+// declares itself.
+type notFoundError struct {
+	Reason string `json:"reason"`
+}
+
+type client struct {
+	Name string `json:"name"`
+}
+
+func TestGenerateGoClient(t *testing.T) {
+	s := edgeCaseService()
+	s.Route(s.GET("/events").To(SampleHandler).
+		Produces("application/json").
+		Writes([]event{}))
+	s.Route(s.GET("/header").To(SampleHandler).
+		Operation("Header").
+		Produces("application/json").
+		Writes(client{}).
+		Returns(404, "missing", notFoundError{}))
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, s.GenerateGoClient(buf, GoClientOptions{Package: "widgets"}))
+	src := buf.String()
+	assert.Contains(t, src, "func (c *Client) GetWidgetByID(ctx context.Context, id string) (Widget, error)")
+	assert.Contains(t, src, "func (c *Client) GetWidgetByID2(ctx context.Context, id string, body Widget) (string, error)")
+	assert.Contains(t, src, "func (c *Client) Add(ctx context.Context, xTrace *string, xTag []string, color *string, limit int64, body Node) ([]Widget, error)")
+	assert.Contains(t, src, "for _, v := range xTag {\n\t\theader.Add(\"X-Tag\", v)\n\t}")
+	assert.Contains(t, src, "func (c *Client) Widgets(ctx context.Context, id string) error")
+	assert.Contains(t, src, `path := "/widgets/" + url.PathEscape(id)`)
+	assert.Contains(t, src, "type NotFoundError struct{ *APIError }")
+	assert.Contains(t, src, "type ConflictError struct{ *APIError }")
+	assert.Contains(t, src, "Children []*Node `json:\"children,omitempty\"`")
+	assert.Contains(t, src, "Created time.Time `json:\"created\"`")
+	assert.Contains(t, src, "\t\"time\"\n")
+	assert.Contains(t, src, "type Client2 struct {")
+	assert.Contains(t, src, "type NotFoundError2 struct {")
+	assert.Contains(t, src, "func (c *Client) Header2(ctx context.Context) (Client2, error)")
+
+	// make sure it compiles, if we can
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go tool")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/widgets\n\ngo 1.18\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "client.go"), buf.Bytes(), 0644)
+	cmd := exec.Command(goTool, "vet", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(out))
+}
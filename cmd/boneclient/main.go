@@ -0,0 +1,47 @@
+// Command boneclient generates a typed Go client package from the /jsondoc
+// output of a boneful service. In the client package, add something like
+//
+//	//go:generate go run github.com/kentquirk/boneful/cmd/boneclient -doc ../api/testdata/api.json -pkg widgets
+//
+// The client can also be generated from a Service value with
+// Service.GenerateGoClient.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/kentquirk/boneful"
+)
+
+func main() {
+	docFile := flag.String("doc", "", "file containing the service's /jsondoc output (required)")
+	pkg := flag.String("pkg", "client", "name of the generated package")
+	out := flag.String("o", "client_gen.go", "output file")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("boneclient: ")
+	if *docFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*docFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc := &boneful.JSONDoc{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		log.Fatalf("reading %s: %v", *docFile, err)
+	}
+	buf := &bytes.Buffer{}
+	if err := boneful.GenerateGoClient(buf, doc, boneful.GoClientOptions{Package: *pkg}); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
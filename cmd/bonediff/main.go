@@ -0,0 +1,66 @@
+// Command bonediff compares two versions of a boneful service's /jsondoc
+// output and reports the differences. It exits with status 1 if any of
+// them would break existing clients, so it can guard API changes in CI:
+//
+//	curl -s http://localhost:8080/jsondoc > new.json
+//	bonediff api.json new.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kentquirk/boneful"
+)
+
+func main() {
+	breakingOnly := flag.Bool("breaking", false, "only report breaking changes")
+	asJSON := flag.Bool("json", false, "report the changes as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: bonediff [flags] old.json new.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("bonediff: ")
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	oldDoc, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	newDoc, err := os.ReadFile(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	changes, err := boneful.DiffJSON(oldDoc, newDoc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	breaking := changes.HasBreaking()
+	if *breakingOnly {
+		changes = changes.Breaking()
+	}
+
+	if *asJSON {
+		if changes == nil {
+			changes = boneful.Changes{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(changes)
+	} else {
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+	}
+	if breaking {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,105 @@
+// Command bonedoc extracts the Go doc comments of the functions in a
+// package and writes a source file that registers them with boneful, so
+// that routes whose Doc or Notes were never set get them from the handler's
+// comment instead. It is meant to be run by go generate:
+//
+//	//go:generate go run github.com/kentquirk/boneful/cmd/bonedoc
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kentquirk/boneful"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package to document")
+	importPath := flag.String("importpath", "", "import path of the package (default from go list)")
+	root := flag.String("root", "", "file names are recorded relative to this directory (default the module root)")
+	out := flag.String("o", "bonedoc_gen.go", "output file, relative to -dir")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("bonedoc: ")
+
+	if *importPath == "" {
+		*importPath = goList(*dir, "{{.ImportPath}}")
+	}
+	if *root == "" {
+		*root = goList(*dir, "{{.Module.Dir}}")
+	}
+
+	docs, err := boneful.ParseHandlerDocs(*dir, *importPath, *root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg, err := packageName(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by bonedoc; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(buf, "import \"github.com/kentquirk/boneful\"\n\n")
+	fmt.Fprintf(buf, "func init() {\n\tboneful.RegisterHandlerDocs(map[string]boneful.HandlerDoc{\n")
+	for _, k := range keys {
+		d := docs[k]
+		fmt.Fprintf(buf, "%q: {Doc: %q, File: %q, Line: %d},\n", k, d.Doc, d.File, d.Line)
+	}
+	fmt.Fprintf(buf, "})\n}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *out), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// goList asks the go tool about the package in dir.
+func goList(dir, format string) string {
+	cmd := exec.Command("go", "list", "-f", format)
+	cmd.Dir = dir
+	b, err := cmd.Output()
+	if err != nil {
+		log.Fatalf("go list in %s: %v", dir, err)
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// packageName returns the name of the (non-test) package in dir.
+func packageName(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	fset := token.NewFileSet()
+	for _, m := range matches {
+		if strings.HasSuffix(m, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, m, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		return f.Name.Name, nil
+	}
+	return "", fmt.Errorf("no Go files in %s", dir)
+}
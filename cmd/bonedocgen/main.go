@@ -0,0 +1,59 @@
+// Command bonedocgen writes the documentation of a boneful service, in
+// every format, from its /jsondoc output, so that the rendered docs can be
+// committed next to the code. The JSON is typically a golden file kept up
+// to date by a test such as
+//
+//	func TestDocs(t *testing.T) {
+//		boneful.AssertDocsGolden(t, NewService(), "testdata/api.json")
+//	}
+//
+// with the rest generated from it by
+//
+//	//go:generate go run github.com/kentquirk/boneful/cmd/bonedocgen -doc testdata/api.json -dir docs
+//
+// A CI job that runs go generate and then checks that git reports no
+// changes will then fail when the API changes without its docs.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/kentquirk/boneful"
+)
+
+func main() {
+	docFile := flag.String("doc", "", "file containing the service's /jsondoc output (required)")
+	dir := flag.String("dir", ".", "directory to write the documentation into")
+	name := flag.String("name", "api", "base name of the files written")
+	formats := flag.String("formats", "", "comma-separated formats to write (default all)")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("bonedocgen: ")
+	if *docFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*docFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc := new(boneful.Service)
+	if err := json.Unmarshal(data, svc); err != nil {
+		log.Fatalf("reading %s: %v", *docFile, err)
+	}
+	var list []string
+	if *formats != "" {
+		list = strings.Split(*formats, ",")
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		log.Fatal(err)
+	}
+	if err := svc.WriteDocs(*dir, *name, list...); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,45 @@
+// Command bonemock serves a mock of a boneful service from its /jsondoc
+// output, so that clients can be developed before the handlers exist:
+//
+//	bonemock -doc api.json -addr :8080
+//
+// Every documented route answers with its documented sample; set the
+// X-Mock-Status header to get one of the documented error responses
+// instead. See Service.MockMux.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/kentquirk/boneful"
+)
+
+func main() {
+	docFile := flag.String("doc", "", "file containing the service's /jsondoc output (required)")
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("bonemock: ")
+	if *docFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*docFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc := new(boneful.Service)
+	if err := json.Unmarshal(data, svc); err != nil {
+		log.Fatalf("reading %s: %v", *docFile, err)
+	}
+	for _, r := range svc.Routes() {
+		log.Printf("mocking %s", r)
+	}
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, svc.MockMux()))
+}
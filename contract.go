@@ -0,0 +1,91 @@
+package boneful
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// ContractTest checks the service's handlers against its documentation.
+// For each route, in a subtest, it sends a request built from the
+// documentation (as for CurlExample) through Mux, and checks that the
+// status is 2xx or one of the codes documented with Returns, that a
+// successful response has a content type the route Produces, and that
+// JSON bodies conform to the schema of the WriteSample, or of the model
+// documented for the status. Planned routes are skipped.
+func ContractTest(t *testing.T, s *Service) bool {
+	t.Helper()
+	mux := s.Mux()
+	defs := make(map[string]*Schema)
+	for k, v := range s.schemas {
+		defs[k] = v
+	}
+	ok := true
+	for _, r := range s.routes {
+		r := r
+		ok = t.Run(r.String(), func(t *testing.T) {
+			if r.Planned {
+				t.Skip("planned")
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, contractRequest(r))
+			problems := contractProblems(r, w, defs)
+			for _, p := range problems {
+				t.Errorf("%s: %s", r, p)
+			}
+			if len(problems) > 0 {
+				t.Logf("response body: %q", w.Body.String())
+			}
+		}) && ok
+	}
+	return ok
+}
+
+// contractRequest builds a request for a route from its documentation.
+// Path variables without a default or allowable value are given "1".
+func contractRequest(r Route) *http.Request {
+	return requestBuilder{pathVar: func(d ParameterData) string {
+		if v := exampleValue(d); v != "" {
+			return url.PathEscape(v)
+		}
+		return "1"
+	}}.build(r).httpRequest()
+}
+
+// contractProblems describes how a response breaks the route's contract.
+func contractProblems(r Route, w *httptest.ResponseRecorder, defs map[string]*Schema) []string {
+	var problems []string
+	var schema *Schema
+	re, documented := r.ResponseErrors[w.Code]
+	if documented {
+		schema = sampleSchema(re.schema, re.Model, defs)
+	}
+	if isSuccess(w.Code) {
+		if schema == nil {
+			schema = sampleSchema(r.writeSchema, r.WriteSample, defs)
+		}
+	} else if !documented {
+		return []string{fmt.Sprintf("status %d is not documented", w.Code)}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if isSuccess(w.Code) && len(r.Produces) > 0 && w.Body.Len() > 0 {
+		produced := false
+		for _, p := range r.Produces {
+			produced = produced || p == mediaType
+		}
+		if !produced {
+			problems = append(problems, fmt.Sprintf("Content-Type is %q, but the route Produces %s", mediaType, strings.Join(r.Produces, ", ")))
+		}
+	}
+	if schema != nil && w.Body.Len() > 0 && strings.Contains(mediaType, "json") {
+		for _, p := range schema.Validate(w.Body.Bytes(), defs) {
+			problems = append(problems, fmt.Sprintf("status %d: %s", w.Code, p))
+		}
+	}
+	return problems
+}
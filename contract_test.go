@@ -0,0 +1,85 @@
+package boneful
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	defs := make(map[string]*Schema)
+	sc := SchemaOf(node{}, defs)
+	assert.Empty(t, sc.Validate([]byte(`{"name":"a","children":[{"name":"b","children":null},null]}`), defs))
+	assert.Equal(t, []string{
+		`$: missing required property "name"`,
+		`$.children[0].name: integer, want string`,
+	}, sc.Validate([]byte(`{"children":[{"name":3}]}`), defs))
+	assert.Equal(t, []string{"$: null, want boneful.node"}, sc.Validate([]byte(`null`), defs))
+
+	sc = SchemaOf(widget{}, defs)
+	assert.Equal(t, []string{"$.size: number, want integer"}, sc.Validate([]byte(`{"id":"w","size":1.5}`), defs))
+	assert.Equal(t, []string{"$: invalid JSON: unexpected EOF"}, sc.Validate([]byte(`{`), defs))
+}
+
+func contractService() *Service {
+	s := new(Service).Path("/widgets")
+	s.Route(s.GET("/:id").To(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("missing") == "yes" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(widget{ID: req.URL.Query().Get(":id"), Size: 3})
+	}).
+		Operation("GetWidget").
+		Param(PathParameter("id", "id").DefaultValue("w1")).
+		Param(QueryParameter("missing", "pretend it's missing")).
+		Produces("application/json").
+		Writes(widget{}).
+		Returns(http.StatusNotFound, "no such widget", nil))
+	s.Route(s.POST("/").To(func(rw http.ResponseWriter, req *http.Request) {
+		var w widget
+		if err := json.NewDecoder(req.Body).Decode(&w); err != nil || w.ID == "" {
+			http.Error(rw, "bad widget", http.StatusBadRequest)
+			return
+		}
+		rw.WriteHeader(http.StatusCreated)
+	}).
+		Operation("AddWidget").
+		Consumes("application/json").
+		Reads(widget{ID: "w2"}).
+		Returns(http.StatusBadRequest, "bad widget", nil))
+	s.DesignFirst(false)
+	s.Route(s.DELETE("/:id").Operation("DeleteWidget"))
+	return s
+}
+
+func TestContractTest(t *testing.T) {
+	assert.True(t, ContractTest(t, contractService()))
+}
+
+func TestContractProblems(t *testing.T) {
+	r := contractService().Routes()[0]
+	defs := make(map[string]*Schema)
+	respond := func(code int, contentType, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.WriteHeader(code)
+		w.WriteString(body)
+		return w
+	}
+
+	assert.Empty(t, contractProblems(r, respond(200, "application/json; charset=utf-8", `{"id":"w","size":1}`), defs))
+	assert.Empty(t, contractProblems(r, respond(404, "text/plain", "not found"), defs))
+	assert.Equal(t, []string{`status 500 is not documented`},
+		contractProblems(r, respond(500, "text/plain", "oops\n"), defs))
+	assert.Equal(t, []string{`Content-Type is "text/plain", but the route Produces application/json`},
+		contractProblems(r, respond(200, "text/plain", "w"), defs))
+	assert.Equal(t, []string{`status 200: $: missing required property "size"`},
+		contractProblems(r, respond(200, "application/json", `{"id":"w"}`), defs))
+}
@@ -0,0 +1,229 @@
+package boneful
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CoverageRecorder serves a Service like its Mux does, and records which
+// of the documented routes, parameters and response codes the requests
+// exercise. It is meant to be shared by the tests of a package, with the
+// report written at the end, for example in TestMain:
+//
+//	var api = boneful.NewCoverageRecorder(NewService())
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		api.Report().WriteText(os.Stdout)
+//		os.Exit(code)
+//	}
+type CoverageRecorder struct {
+	s       *Service
+	handler http.Handler
+
+	mu    sync.Mutex
+	stats map[string]*routeStats // by route
+}
+
+type routeStats struct {
+	requests int
+	params   map[string]bool
+	codes    map[int]int
+}
+
+// NewCoverageRecorder wraps the handlers of the service to record the
+// coverage of its routes.
+func NewCoverageRecorder(s *Service) *CoverageRecorder {
+	c := &CoverageRecorder{s: s, stats: make(map[string]*routeStats)}
+	c.handler = s.muxWith(func(r Route, h http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			params := exercisedParams(r, req)
+			sw := &statusWriter{ResponseWriter: rw, code: http.StatusOK}
+			h(sw, req)
+			c.record(r, params, sw.code)
+		}
+	})
+	return c
+}
+
+// ServeHTTP handles the request as the service's Mux would.
+func (c *CoverageRecorder) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	c.handler.ServeHTTP(rw, req)
+}
+
+func (c *CoverageRecorder) record(r Route, params []string, code int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.stats[r.String()]
+	if !ok {
+		st = &routeStats{params: make(map[string]bool), codes: make(map[int]int)}
+		c.stats[r.String()] = st
+	}
+	st.requests++
+	for _, p := range params {
+		st.params[p] = true
+	}
+	st.codes[code]++
+}
+
+// exercisedParams lists the documented parameters that a request sets,
+// as "kind name". Form values are parsed here, so that the handler can
+// still read them after the body has been consumed.
+func exercisedParams(r Route, req *http.Request) []string {
+	var names []string
+	query := req.URL.Query()
+	for _, p := range r.ParameterDocs {
+		d := p.Data()
+		set := false
+		switch d.Kind {
+		case PathParameterKind:
+			set = true
+		case QueryParameterKind:
+			_, set = query[d.Name]
+		case HeaderParameterKind:
+			set = req.Header.Get(d.Name) != ""
+		case FormParameterKind:
+			if req.ParseForm() == nil {
+				_, set = req.PostForm[d.Name]
+			}
+		case BodyParameterKind:
+			set = req.ContentLength != 0
+		}
+		if set {
+			names = append(names, paramLabel(d))
+		}
+	}
+	return names
+}
+
+func paramLabel(d ParameterData) string {
+	return strings.ToLower(d.ParameterKind()) + " " + d.Name
+}
+
+// statusWriter remembers the status of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.code, sw.wroteHeader = code, true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(b)
+}
+
+// CoverageReport is what a CoverageRecorder saw.
+type CoverageReport struct {
+	Routes       []RouteCoverage `json:"routes"`
+	Untested     []string        `json:"untested"`     // operations that were never called
+	Undocumented []string        `json:"undocumented"` // "METHOD /path: code" for codes that aren't documented
+	Tested       int             `json:"tested"`       // number of routes that were called
+}
+
+// RouteCoverage is the coverage of one route.
+type RouteCoverage struct {
+	Route             string      `json:"route"`
+	Operation         string      `json:"operation"`
+	Requests          int         `json:"requests"`
+	Codes             map[int]int `json:"codes,omitempty"` // responses by status
+	UntestedCodes     []int       `json:"untestedCodes,omitempty"`
+	UndocumentedCodes []int       `json:"undocumentedCodes,omitempty"`
+	UntestedParams    []string    `json:"untestedParams,omitempty"`
+}
+
+// Report summarizes the coverage so far, in route order. A response code
+// is undocumented if it isn't 2xx and wasn't documented with Returns.
+func (c *CoverageRecorder) Report() *CoverageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rep := &CoverageReport{Routes: []RouteCoverage{}, Untested: []string{}, Undocumented: []string{}}
+	for _, r := range c.s.routes {
+		rc := RouteCoverage{Route: r.String(), Operation: r.Operation}
+		st, ok := c.stats[r.String()]
+		if !ok {
+			st = &routeStats{}
+			rep.Untested = append(rep.Untested, r.Operation)
+		} else {
+			rep.Tested++
+			rc.Requests = st.requests
+			// a copy, since requests may still be coming in
+			rc.Codes = make(map[int]int, len(st.codes))
+			for code, n := range st.codes {
+				rc.Codes[code] = n
+			}
+		}
+		for code := range r.ResponseErrors {
+			if st.codes[code] == 0 {
+				rc.UntestedCodes = append(rc.UntestedCodes, code)
+			}
+		}
+		for code := range st.codes {
+			if _, ok := r.ResponseErrors[code]; !ok && !isSuccess(code) {
+				rc.UndocumentedCodes = append(rc.UndocumentedCodes, code)
+			}
+		}
+		sort.Ints(rc.UntestedCodes)
+		sort.Ints(rc.UndocumentedCodes)
+		for _, code := range rc.UndocumentedCodes {
+			rep.Undocumented = append(rep.Undocumented, fmt.Sprintf("%s: %d", r, code))
+		}
+		for _, p := range r.ParameterDocs {
+			if label := paramLabel(p.Data()); !st.params[label] {
+				rc.UntestedParams = append(rc.UntestedParams, label)
+			}
+		}
+		rep.Routes = append(rep.Routes, rc)
+	}
+	return rep
+}
+
+// WriteText writes the report for people to read.
+func (rep *CoverageReport) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "API coverage: %d of %d routes tested\n", rep.Tested, len(rep.Routes))
+	for _, rc := range rep.Routes {
+		status := "ok"
+		if rc.Requests == 0 {
+			status = "UNTESTED"
+		}
+		fmt.Fprintf(b, "  %-8s %s (%s), %d requests\n", status, rc.Route, rc.Operation, rc.Requests)
+		if len(rc.UntestedCodes) > 0 {
+			fmt.Fprintf(b, "           untested codes: %s\n", joinInts(rc.UntestedCodes))
+		}
+		if len(rc.UntestedParams) > 0 {
+			fmt.Fprintf(b, "           untested parameters: %s\n", strings.Join(rc.UntestedParams, ", "))
+		}
+		if len(rc.UndocumentedCodes) > 0 {
+			fmt.Fprintf(b, "           UNDOCUMENTED codes: %s\n", joinInts(rc.UndocumentedCodes))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as JSON, for tools.
+func (rep *CoverageReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+func joinInts(list []int) string {
+	s := make([]string, len(list))
+	for i, n := range list {
+		s[i] = fmt.Sprint(n)
+	}
+	return strings.Join(s, ", ")
+}
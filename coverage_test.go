@@ -0,0 +1,96 @@
+package boneful
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageRecorder(t *testing.T) {
+	s := new(Service).Path("/api")
+	s.Route(s.GET("/widgets/:id").To(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("fail") != "" {
+			http.Error(rw, "oops", http.StatusInternalServerError)
+		}
+	}).
+		Operation("GetWidget").
+		Param(PathParameter("id", "id")).
+		Param(QueryParameter("fail", "fail")).
+		Param(HeaderParameter("X-Trace", "trace")).
+		Returns(http.StatusNotFound, "no such widget", nil))
+	s.Route(s.POST("/widgets").To(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "3", req.FormValue("size"))
+		rw.WriteHeader(http.StatusCreated)
+	}).
+		Operation("AddWidget").
+		Param(FormParameter("size", "size")).
+		Returns(http.StatusCreated, "created", nil))
+	s.Route(s.DELETE("/widgets/:id").To(SampleHandler).Operation("DeleteWidget"))
+
+	c := NewCoverageRecorder(s)
+	send := func(req *http.Request) {
+		c.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send(httptest.NewRequest("GET", "/api/widgets/1", nil))
+	send(httptest.NewRequest("GET", "/api/widgets/1?fail=yes", nil))
+	req := httptest.NewRequest("POST", "/api/widgets", strings.NewReader(url.Values{"size": {"3"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	send(req)
+
+	rep := c.Report()
+	assert.Equal(t, 2, rep.Tested)
+	assert.Equal(t, []string{"DeleteWidget"}, rep.Untested)
+	assert.Equal(t, []string{"GET /api/widgets/:id: 500"}, rep.Undocumented)
+	assert.Equal(t, RouteCoverage{
+		Route:             "GET /api/widgets/:id",
+		Operation:         "GetWidget",
+		Requests:          2,
+		Codes:             map[int]int{200: 1, 500: 1},
+		UntestedCodes:     []int{404},
+		UndocumentedCodes: []int{500},
+		UntestedParams:    []string{"header X-Trace"},
+	}, rep.Routes[0])
+	assert.Equal(t, RouteCoverage{
+		Route:     "POST /api/widgets",
+		Operation: "AddWidget",
+		Requests:  1,
+		Codes:     map[int]int{201: 1},
+	}, rep.Routes[1])
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, rep.WriteJSON(buf))
+	var decoded CoverageReport
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *rep, decoded)
+
+	buf.Reset()
+	assert.Nil(t, rep.WriteText(buf))
+	assert.Equal(t, `API coverage: 2 of 3 routes tested
+  ok       GET /api/widgets/:id (GetWidget), 2 requests
+           untested codes: 404
+           untested parameters: header X-Trace
+           UNDOCUMENTED codes: 500
+  ok       POST /api/widgets (AddWidget), 1 requests
+  UNTESTED DELETE /api/widgets/:id (DeleteWidget), 0 requests
+`, buf.String())
+}
+
+func TestCoverageReportSnapshot(t *testing.T) {
+	s := new(Service).Path("/api")
+	s.Route(s.GET("/ping").To(SampleHandler))
+	c := NewCoverageRecorder(s)
+	get := func() {
+		c.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/ping", nil))
+	}
+	get()
+	rep := c.Report()
+	get()
+	assert.Equal(t, map[int]int{200: 1}, rep.Routes[0].Codes)
+	assert.Equal(t, map[int]int{200: 2}, c.Report().Routes[0].Codes)
+}
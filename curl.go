@@ -0,0 +1,61 @@
+package boneful
+
+import (
+	"net/url"
+	"strings"
+)
+
+// CurlExample returns a curl command line that calls the route on the
+// first of the Servers, with the defaults for its variables. Parameters
+// are given their default or first allowable value; path variables
+// without one are left as placeholders like <id>, and optional
+// parameters without one are left out. The body is the ReadSample, sent
+// as the first content type the route Consumes.
+func (s *Service) CurlExample(r Route) string {
+	req := requestBuilder{pathVar: func(d ParameterData) string {
+		if v := exampleValue(d); v != "" {
+			return url.PathEscape(v)
+		}
+		return "<" + d.Name + ">"
+	}}.build(r)
+
+	args := []string{"curl"}
+	switch r.Method {
+	case "GET":
+	case "HEAD":
+		args = append(args, "--head")
+	default:
+		args = append(args, "-X "+r.Method)
+	}
+	args = append(args, shellQuote(strings.TrimRight(s.exampleServer().Expand(), "/")+req.target()))
+	cmd := strings.Join(args, " ")
+
+	args = nil
+	if req.accept != "" {
+		args = append(args, "-H "+shellQuote("Accept: "+req.accept))
+	}
+	for _, h := range req.header {
+		if !h.omitted {
+			args = append(args, "-H "+shellQuote(h.Name+": "+h.value))
+		}
+	}
+	if req.body != "" {
+		args = append(args, "-H "+shellQuote("Content-Type: "+req.contentType))
+		args = append(args, "-d "+shellQuote(req.body))
+	} else {
+		for _, f := range req.form {
+			if !f.omitted {
+				args = append(args, "--data-urlencode "+shellQuote(f.Name+"="+f.value))
+			}
+		}
+	}
+	for _, a := range args {
+		cmd += " \\\n  " + a
+	}
+	return cmd
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
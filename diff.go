@@ -0,0 +1,414 @@
+package boneful
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Change is one difference between two versions of a Service's API.
+type Change struct {
+	Route    string `json:"route"` // "METHOD /path" of the route affected
+	Breaking bool   `json:"breaking"`
+	Message  string `json:"message"`
+}
+
+func (c Change) String() string {
+	kind := "non-breaking"
+	if c.Breaking {
+		kind = "BREAKING"
+	}
+	return fmt.Sprintf("%s %s: %s", kind, c.Route, c.Message)
+}
+
+// Changes is the result of Diff.
+type Changes []Change
+
+// Breaking returns only the breaking changes.
+func (cs Changes) Breaking() Changes {
+	var b Changes
+	for _, c := range cs {
+		if c.Breaking {
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// HasBreaking reports whether any of the changes would break a client.
+func (cs Changes) HasBreaking() bool {
+	return len(cs.Breaking()) > 0
+}
+
+// routeKey identifies a route independently of the names given to its
+// path parameters, so that renaming :id to :widgetID, or #id^[0-9]+$ to
+// #num^[0-9]+$, isn't a new route.
+func routeKey(r Route) string {
+	segs := strings.Split(r.Path, "/")
+	for i, seg := range segs {
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			segs[i] = ":"
+		case strings.HasPrefix(seg, "#"):
+			if ix := strings.Index(seg, "^"); ix >= 0 {
+				segs[i] = "#" + seg[ix:]
+			} else {
+				segs[i] = "#"
+			}
+		}
+	}
+	return r.Method + " " + strings.Join(segs, "/")
+}
+
+// Diff compares two versions of a service and classifies each difference
+// as breaking or not, from the point of view of an existing client.
+// Removing a route, a response code, an allowable value or a consumed or
+// produced media type is breaking, as are new required parameters and
+// changed data types. Bodies are compared by their schemas: removing or
+// retyping a response field is breaking, as is a new required request
+// field. Additions are not. Use DiffJSON to compare the documentation of
+// services that aren't available as Go values.
+func Diff(old, new *Service) Changes {
+	var changes Changes
+	add := func(route string, breaking bool, format string, args ...interface{}) {
+		changes = append(changes, Change{route, breaking, fmt.Sprintf(format, args...)})
+	}
+
+	newRoutes := make(map[string]Route)
+	for _, r := range new.Routes() {
+		newRoutes[routeKey(r)] = r
+	}
+	oldRoutes := make(map[string]Route)
+	for _, r := range old.Routes() {
+		oldRoutes[routeKey(r)] = r
+	}
+
+	oldDefs, newDefs := old.modelDefs(), new.modelDefs()
+	for _, or := range old.Routes() {
+		nr, ok := newRoutes[routeKey(or)]
+		if !ok {
+			add(or.String(), true, "route was removed")
+			continue
+		}
+		diffRoute(or, nr, add)
+		sd := &schemaDiff{route: nr.String(), oldDefs: oldDefs, newDefs: newDefs, add: add}
+		sd.body("request body", false,
+			sampleSchema(or.readSchema, or.ReadSample, oldDefs), sampleSchema(nr.readSchema, nr.ReadSample, newDefs))
+		sd.body("response body", true,
+			sampleSchema(or.writeSchema, or.WriteSample, oldDefs), sampleSchema(nr.writeSchema, nr.WriteSample, newDefs))
+		for code, ore := range or.ResponseErrors {
+			if nre, ok := nr.ResponseErrors[code]; ok {
+				sd.body(fmt.Sprintf("response %d body", code), true,
+					sampleSchema(ore.schema, ore.Model, oldDefs), sampleSchema(nre.schema, nre.Model, newDefs))
+			}
+		}
+	}
+	for _, nr := range new.Routes() {
+		if _, ok := oldRoutes[routeKey(nr)]; !ok {
+			add(nr.String(), false, "route was added")
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Breaking != changes[j].Breaking {
+			return changes[i].Breaking
+		}
+		if changes[i].Route != changes[j].Route {
+			return changes[i].Route < changes[j].Route
+		}
+		return changes[i].Message < changes[j].Message
+	})
+	return changes
+}
+
+func diffRoute(or, nr Route, add func(string, bool, string, ...interface{})) {
+	route := nr.String()
+
+	paramKey := func(d ParameterData) string {
+		if d.Kind == PathParameterKind {
+			return "path"
+		}
+		return strings.ToLower(d.ParameterKind()) + " " + d.Name
+	}
+	// path parameters are matched by position, since their names can change
+	index := func(r Route) (map[string]ParameterData, []string) {
+		m := make(map[string]ParameterData)
+		var keys []string
+		npath := 0
+		for _, p := range r.ParameterDocs {
+			d := p.Data()
+			k := paramKey(d)
+			if k == "path" {
+				npath++
+				k = fmt.Sprintf("path #%d", npath)
+			}
+			m[k] = d
+			keys = append(keys, k)
+		}
+		return m, keys
+	}
+	oldParams, oldKeys := index(or)
+	newParams, newKeys := index(nr)
+
+	for _, k := range oldKeys {
+		od := oldParams[k]
+		nd, ok := newParams[k]
+		if !ok {
+			add(route, false, "%s parameter %s was removed", strings.ToLower(od.ParameterKind()), od.Name)
+			continue
+		}
+		name := fmt.Sprintf("%s parameter %s", strings.ToLower(nd.ParameterKind()), nd.Name)
+		if !od.Required && nd.Required {
+			add(route, true, "%s is now required", name)
+		} else if od.Required && !nd.Required {
+			add(route, false, "%s is now optional", name)
+		}
+		// a body's type is compared by its schema, so renaming it is fine
+		if od.DataType != nd.DataType && od.Kind != BodyParameterKind {
+			add(route, true, "%s changed type from %q to %q", name, od.DataType, nd.DataType)
+		}
+		if od.AllowMultiple && !nd.AllowMultiple {
+			add(route, true, "%s no longer allows multiple values", name)
+		}
+		diffAllowable(name, od.AllowableValues, nd.AllowableValues, route, add)
+	}
+	for _, k := range newKeys {
+		if _, ok := oldParams[k]; ok {
+			continue
+		}
+		nd := newParams[k]
+		name := fmt.Sprintf("%s parameter %s", strings.ToLower(nd.ParameterKind()), nd.Name)
+		if nd.Required {
+			add(route, true, "required %s was added", name)
+		} else {
+			add(route, false, "optional %s was added", name)
+		}
+	}
+
+	for code := range or.ResponseErrors {
+		if _, ok := nr.ResponseErrors[code]; !ok {
+			add(route, true, "response code %d was removed", code)
+		}
+	}
+	for code := range nr.ResponseErrors {
+		if _, ok := or.ResponseErrors[code]; !ok {
+			add(route, false, "response code %d was added", code)
+		}
+	}
+
+	diffMediaTypes("consumes", or.Consumes, nr.Consumes, route, add)
+	diffMediaTypes("produces", or.Produces, nr.Produces, route, add)
+}
+
+func diffAllowable(name string, old, new map[string]string, route string, add func(string, bool, string, ...interface{})) {
+	if len(new) == 0 {
+		if len(old) != 0 {
+			add(route, false, "%s no longer restricts its values", name)
+		}
+		return
+	}
+	if len(old) == 0 {
+		add(route, true, "%s now restricts its values", name)
+		return
+	}
+	var removed, added []string
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	for k := range new {
+		if _, ok := old[k]; !ok {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	if len(removed) > 0 {
+		add(route, true, "%s no longer allows %s", name, strings.Join(removed, ", "))
+	}
+	if len(added) > 0 {
+		add(route, false, "%s now also allows %s", name, strings.Join(added, ", "))
+	}
+}
+
+func diffMediaTypes(what string, old, new []string, route string, add func(string, bool, string, ...interface{})) {
+	has := func(list []string, s string) bool {
+		for _, each := range list {
+			if each == s {
+				return true
+			}
+		}
+		return false
+	}
+	for _, mt := range old {
+		if !has(new, mt) {
+			add(route, true, "no longer %s %s", what, mt)
+		}
+	}
+	for _, mt := range new {
+		if !has(old, mt) {
+			add(route, false, "now also %s %s", what, mt)
+		}
+	}
+}
+
+// DiffJSON compares two JSON documents in the /jsondoc format.
+func DiffJSON(oldDoc, newDoc []byte) (Changes, error) {
+	oldSvc, newSvc := new(Service), new(Service)
+	if err := json.Unmarshal(oldDoc, oldSvc); err != nil {
+		return nil, fmt.Errorf("reading old documentation: %v", err)
+	}
+	if err := json.Unmarshal(newDoc, newSvc); err != nil {
+		return nil, fmt.Errorf("reading new documentation: %v", err)
+	}
+	return Diff(oldSvc, newSvc), nil
+}
+
+// schemaDiff compares the schemas of the bodies of a route. Clients read
+// responses and write requests, so a change that is harmless in one
+// direction can be breaking in the other.
+type schemaDiff struct {
+	route            string
+	oldDefs, newDefs map[string]*Schema
+	add              func(string, bool, string, ...interface{})
+	seen             map[[2]string]bool // pairs of refs already compared
+}
+
+func (sd *schemaDiff) body(what string, response bool, old, new *Schema) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		// a new request body shows up as a new body parameter
+		if response {
+			sd.add(sd.route, false, "%s was added", what)
+		}
+		return
+	case new == nil:
+		if response {
+			sd.add(sd.route, true, "%s was removed", what)
+		}
+		return
+	}
+	sd.seen = make(map[[2]string]bool)
+	sd.compare(what, "", response, old, new)
+}
+
+// resolveSchema follows a ref to the named schema.
+func resolveSchema(sc *Schema, defs map[string]*Schema) *Schema {
+	if sc == nil || sc.Ref == "" {
+		return sc
+	}
+	if def, ok := defs[sc.RefName()]; ok {
+		return def
+	}
+	return &Schema{}
+}
+
+// schemaKind describes the JSON type of a schema for messages.
+func schemaKind(sc *Schema) string {
+	if sc.Type == "" {
+		return "any"
+	}
+	if sc.Type == "string" && sc.Format != "" {
+		return sc.Type + " (" + sc.Format + ")"
+	}
+	return sc.Type
+}
+
+func (sd *schemaDiff) compare(what, path string, response bool, old, new *Schema) {
+	if old == nil || new == nil {
+		return
+	}
+	if old.Ref != "" && new.Ref != "" {
+		pair := [2]string{old.Ref, new.Ref}
+		if sd.seen[pair] {
+			return
+		}
+		sd.seen[pair] = true
+	}
+	old, new = resolveSchema(old, sd.oldDefs), resolveSchema(new, sd.newDefs)
+	name := what
+	if path != "" {
+		name = what + " field " + path
+	}
+	// integer formats are Go sizes, which don't change the JSON
+	retyped := old.Type != new.Type || old.Type == "string" && old.Format != new.Format
+	if old.Type != "" && new.Type != "" && retyped {
+		sd.add(sd.route, true, "%s changed type from %s to %s", name, schemaKind(old), schemaKind(new))
+		return
+	}
+	if old.Type != "" && new.Type == "" {
+		// anything is now allowed: only clients reading it can be surprised
+		sd.add(sd.route, response, "%s is no longer typed", name)
+		return
+	}
+
+	var removed, added []string
+	for _, v := range old.Enum {
+		if !inEnum(new.Enum, v) {
+			removed = append(removed, v)
+		}
+	}
+	for _, v := range new.Enum {
+		if !inEnum(old.Enum, v) {
+			added = append(added, v)
+		}
+	}
+	if len(old.Enum) > 0 && len(removed) > 0 {
+		sd.add(sd.route, !response, "%s no longer allows %s", name, strings.Join(removed, ", "))
+	}
+	if len(old.Enum) > 0 && len(added) > 0 {
+		sd.add(sd.route, response, "%s now also allows %s", name, strings.Join(added, ", "))
+	}
+
+	join := func(field string) string {
+		if path == "" {
+			return field
+		}
+		return path + "." + field
+	}
+	props := make([]string, 0, len(old.Properties)+len(new.Properties))
+	for p := range old.Properties {
+		props = append(props, p)
+	}
+	for p := range new.Properties {
+		if _, ok := old.Properties[p]; !ok {
+			props = append(props, p)
+		}
+	}
+	sort.Strings(props)
+	for _, p := range props {
+		op, inOld := old.Properties[p]
+		np, inNew := new.Properties[p]
+		oldReq, newReq := inEnum(old.Required, p), inEnum(new.Required, p)
+		field := what + " field " + join(p)
+		switch {
+		case !inNew:
+			// clients may rely on a response field; a server may just
+			// ignore a request field it no longer reads
+			sd.add(sd.route, response, "%s was removed", field)
+		case !inOld:
+			if !response && newReq {
+				sd.add(sd.route, true, "required %s was added", field)
+			} else {
+				sd.add(sd.route, false, "%s was added", field)
+			}
+		default:
+			if !response && !oldReq && newReq {
+				sd.add(sd.route, true, "%s is now required", field)
+			} else if response && oldReq && !newReq {
+				sd.add(sd.route, true, "%s may now be omitted", field)
+			}
+			sd.compare(what, join(p), response, op, np)
+		}
+	}
+	if old.Items != nil && new.Items != nil {
+		sd.compare(what, path+"[]", response, old.Items, new.Items)
+	}
+	if old.AdditionalProperties != nil && new.AdditionalProperties != nil {
+		sd.compare(what, path+"{}", response, old.AdditionalProperties, new.AdditionalProperties)
+	}
+}
@@ -0,0 +1,114 @@
+package boneful
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func diffService(v2 bool) *Service {
+	s := new(Service).Path("/api")
+	color := QueryParameter("color", "Color filter").
+		AllowableValues(map[string]string{"red": "Red", "blue": "Blue"})
+	get := s.GET("/things/:id").To(SampleHandler).
+		Param(PathParameter("id", "Thing id")).
+		Param(color).
+		Returns(http.StatusNotFound, "no such thing", nil)
+	if v2 {
+		get = s.GET("/things/:thingID").To(SampleHandler).
+			Param(PathParameter("thingID", "Thing id")).
+			Param(color.AllowableValues(map[string]string{"red": "Red", "green": "Green"})).
+			Param(HeaderParameter("X-Tenant", "Tenant").Required(true))
+	}
+	s.Route(get)
+	if v2 {
+		s.Route(s.POST("/things").To(SampleHandler).Operation("Add"))
+	} else {
+		s.Route(s.DELETE("/things/:id").To(SampleHandler).Operation("Remove"))
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	changes := Diff(diffService(false), diffService(true))
+	var msgs []string
+	for _, c := range changes {
+		msgs = append(msgs, c.String())
+	}
+	assert.Equal(t, []string{
+		"BREAKING DELETE /api/things/:id: route was removed",
+		"BREAKING GET /api/things/:thingID: query parameter color no longer allows blue",
+		"BREAKING GET /api/things/:thingID: required header parameter X-Tenant was added",
+		"BREAKING GET /api/things/:thingID: response code 404 was removed",
+		"non-breaking GET /api/things/:thingID: query parameter color now also allows green",
+		"non-breaking POST /api/things: route was added",
+	}, msgs)
+	assert.True(t, changes.HasBreaking())
+	assert.False(t, Diff(diffService(true), diffService(true)).HasBreaking())
+
+	oldDoc, _ := json.Marshal(diffService(false))
+	newDoc, _ := json.Marshal(diffService(true))
+	fromJSON, err := DiffJSON(oldDoc, newDoc)
+	assert.Nil(t, err)
+	assert.Equal(t, changes, fromJSON)
+}
+
+type orderV1 struct {
+	ID     string `json:"id"`
+	Size   int    `json:"size"`
+	Note   string `json:"note,omitempty"`
+	Status string `json:"status" enum:"open,closed"`
+}
+
+type orderV2 struct {
+	ID     string `json:"id"`
+	Size   string `json:"size"`
+	Status string `json:"status" enum:"open,closed,held"`
+	Lines  []int  `json:"lines,omitempty"`
+}
+
+type newOrderV1 struct {
+	Name string `json:"name"`
+	Memo string `json:"memo,omitempty"`
+}
+
+type newOrderV2 struct {
+	Name  string `json:"name"`
+	Memo  string `json:"memo"`
+	Owner string `json:"owner"`
+}
+
+func TestDiffBodies(t *testing.T) {
+	v1 := new(Service).Path("/api")
+	v1.Route(v1.POST("/orders/#id^[0-9]+$").To(SampleHandler).
+		Reads(newOrderV1{}).Writes(orderV1{}).
+		Returns(http.StatusConflict, "exists", orderV1{}))
+	v2 := new(Service).Path("/api")
+	v2.Route(v2.POST("/orders/#num^[0-9]+$").To(SampleHandler).
+		Reads(newOrderV2{}).Writes(orderV2{}).
+		Returns(http.StatusConflict, "exists", nil))
+
+	changes := Diff(v1, v2)
+	var msgs []string
+	for _, c := range changes {
+		msgs = append(msgs, c.String())
+	}
+	assert.Equal(t, []string{
+		"BREAKING POST /api/orders/#num^[0-9]+$: request body field memo is now required",
+		"BREAKING POST /api/orders/#num^[0-9]+$: required request body field owner was added",
+		"BREAKING POST /api/orders/#num^[0-9]+$: response 409 body was removed",
+		"BREAKING POST /api/orders/#num^[0-9]+$: response body field note was removed",
+		"BREAKING POST /api/orders/#num^[0-9]+$: response body field size changed type from integer to string",
+		"BREAKING POST /api/orders/#num^[0-9]+$: response body field status now also allows held",
+		"non-breaking POST /api/orders/#num^[0-9]+$: response body field lines was added",
+	}, msgs)
+
+	oldDoc, _ := json.Marshal(v1)
+	newDoc, _ := json.Marshal(v2)
+	fromJSON, err := DiffJSON(oldDoc, newDoc)
+	assert.Nil(t, err)
+	assert.Equal(t, changes, fromJSON)
+	assert.Empty(t, Diff(v2, v2))
+}
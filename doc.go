@@ -33,10 +33,19 @@ to the API definitions and somewhat larger mods to the handlers.
 Finally, it automatically adds a couple of endpoints to the API,
 provided that the API doesn't already define them:
 
+* /doc -- delivers the documentation in whichever format the client
+	asks for with its Accept header or a ?format= query (markdown, json,
+	yaml or html, plus any added with RegisterDocRenderer).
 * /md -- delivers the documentation in .md (markdown) form, intended
 	to be compatible with GitHub's GFM.
 * /jsondoc -- returns the documentation information as JSON (could be
-	used in a swagger-like style.
+	used in a swagger-like style). The format is versioned and described
+	by the JSONDoc type; a Service can be read back from it.
+* /docs -- an interactive HTML page, with no external dependencies, that
+	shows the documentation and lets you try out each route.
 * /health -- returns 200 and "OK" (if you want your app to be smarter,
 	simply set up your own /health endpoint)
+
+With ValidateResponses, it also adds /debug/validation, which counts the
+responses that didn't match the documentation.
 */
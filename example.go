@@ -0,0 +1,169 @@
+package boneful
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+// SynthesizeExample builds example JSON for the type of sample, for
+// documenting its shape when the sample itself is just a zero value.
+// Struct fields take their value from an `example:"..."` tag if they have
+// one (as JSON, or as a plain string for string fields); otherwise values
+// are placeholders for their type. Slices get one element and maps one
+// key. A type that contains itself is cut short: a pointer back to it is
+// null, and a slice or map of it is empty.
+func SynthesizeExample(sample interface{}) json.RawMessage {
+	if sample == nil {
+		return json.RawMessage("null")
+	}
+	buf := &bytes.Buffer{}
+	synthesize(buf, reflect.TypeOf(sample), "", make(map[reflect.Type]bool))
+	return buf.Bytes()
+}
+
+// exampleOf is the sample to show for a Reads, Writes or Returns value:
+// the sample itself, unless it is an empty value whose type can show more.
+func exampleOf(sample interface{}) interface{} {
+	if sample == nil {
+		return nil
+	}
+	v := reflect.ValueOf(sample)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType || v.NumField() == 0 || !v.IsZero() {
+			return sample
+		}
+	case reflect.Slice, reflect.Map:
+		if v.Len() > 0 || v.Type() == rawMessageType {
+			return sample
+		}
+	case reflect.Ptr:
+		// a nil pointer to a struct
+	default:
+		return sample
+	}
+	return SynthesizeExample(sample)
+}
+
+func synthesize(buf *bytes.Buffer, t reflect.Type, tag string, seen map[reflect.Type]bool) {
+	if tag != "" {
+		if t.Kind() == reflect.String || !json.Valid([]byte(tag)) {
+			b, _ := json.Marshal(tag)
+			buf.Write(b)
+		} else {
+			buf.WriteString(tag)
+		}
+		return
+	}
+
+	if t.Kind() == reflect.Ptr {
+		if seen[t.Elem()] {
+			buf.WriteString("null")
+			return
+		}
+		synthesize(buf, t.Elem(), "", seen)
+		return
+	}
+	switch {
+	case t == timeType:
+		buf.WriteString(`"2006-01-02T15:04:05Z"`)
+		return
+	case t == rawMessageType:
+		buf.WriteString("{}")
+		return
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		if b, err := json.Marshal(reflect.New(t).Interface()); err == nil {
+			buf.Write(b)
+			return
+		}
+		buf.WriteString("null")
+		return
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		buf.WriteString(`"string"`)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		buf.WriteString("true")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.WriteString("1")
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString("1.5")
+	case reflect.String:
+		buf.WriteString(`"string"`)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			// []byte is encoded as base64
+			buf.WriteString(`"Ynl0ZXM="`)
+			return
+		}
+		if seen[t] || seen[derefType(t.Elem())] {
+			buf.WriteString("[]")
+			return
+		}
+		seen[t] = true
+		buf.WriteString("[")
+		synthesize(buf, t.Elem(), "", seen)
+		buf.WriteString("]")
+		delete(seen, t)
+	case reflect.Map:
+		if seen[t] || seen[derefType(t.Elem())] {
+			buf.WriteString("{}")
+			return
+		}
+		seen[t] = true
+		key := "key"
+		switch t.Key().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			key = "1"
+		}
+		buf.WriteString(`{"` + key + `":`)
+		synthesize(buf, t.Elem(), "", seen)
+		buf.WriteString("}")
+		delete(seen, t)
+	case reflect.Struct:
+		if seen[t] {
+			buf.WriteString("null")
+			return
+		}
+		seen[t] = true
+		buf.WriteString("{")
+		for i, f := range jsonFields(t) {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			name, _ := json.Marshal(f.Name)
+			buf.Write(name)
+			buf.WriteString(":")
+			if f.asString && f.Tag.Get("example") == "" {
+				// the value is encoded inside a string
+				inner := &bytes.Buffer{}
+				synthesize(inner, f.Type, "", seen)
+				buf.WriteString(strconv.Quote(inner.String()))
+				continue
+			}
+			synthesize(buf, f.Type, f.Tag.Get("example"), seen)
+		}
+		buf.WriteString("}")
+		delete(seen, t)
+	default:
+		// interfaces, and things JSON can't encode anyway
+		buf.WriteString("null")
+	}
+}
+
+// derefType is the type a (pointer) type points to in the end.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
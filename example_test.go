@@ -0,0 +1,173 @@
+package boneful
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	Street string `json:"street" example:"1 Main St"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type customer struct {
+	ID       int64             `json:"id" example:"42"`
+	Name     string            `json:"name" example:"Ada"`
+	Tags     []string          `json:"tags" example:"[\"vip\",\"new\"]"`
+	Score    float64           `json:"score"`
+	Active   bool              `json:"active"`
+	Joined   time.Time         `json:"joined"`
+	Count    int               `json:"count,string"`
+	Home     address           `json:"home"`
+	Previous []address         `json:"previous"`
+	Attrs    map[string]int    `json:"attrs"`
+	Referrer *customer         `json:"referrer"`
+	Extra    interface{}       `json:"extra"`
+	Raw      json.RawMessage   `json:"raw"`
+	Photo    []byte            `json:"photo"`
+	internal string            //nolint:unused
+	Ignored  string            `json:"-"`
+	ByYear   map[int][]address `json:"byYear"`
+	*address                   // embedded fields are inlined
+}
+
+func TestSynthesizeExample(t *testing.T) {
+	assert.JSONEq(t, `{
+		"id": 42, "name": "Ada", "tags": ["vip", "new"], "score": 1.5, "active": true,
+		"joined": "2006-01-02T15:04:05Z", "count": "1",
+		"home": {"street": "1 Main St", "zip": "string"},
+		"previous": [{"street": "1 Main St", "zip": "string"}],
+		"attrs": {"key": 1},
+		"referrer": null,
+		"extra": null, "raw": {}, "photo": "Ynl0ZXM=",
+		"byYear": {"1": [{"street": "1 Main St", "zip": "string"}]},
+		"street": "1 Main St", "zip": "string"
+	}`, string(SynthesizeExample(customer{})))
+
+	// fields come out in declaration order
+	assert.Equal(t, `{"street":"1 Main St","zip":"string"}`, string(SynthesizeExample(&address{})))
+	assert.Equal(t, `{"name":"string","children":[]}`, string(SynthesizeExample(node{})))
+	assert.Equal(t, `{"name":"string","entries":{}}`, string(SynthesizeExample(directory{})))
+	assert.Equal(t, `[{"id":"string","size":1}]`, string(SynthesizeExample([]widget{})))
+}
+
+type directory struct {
+	Name    string                `json:"name"`
+	Entries map[string]*directory `json:"entries"`
+}
+
+func TestExampleOf(t *testing.T) {
+	w := widget{ID: "w1"}
+	assert.Equal(t, w, exampleOf(w))
+	assert.Equal(t, "text", exampleOf("text"))
+	assert.Equal(t, 0, exampleOf(0))
+	assert.Equal(t, readstr{}, exampleOf(readstr{}))
+	assert.Nil(t, exampleOf(nil))
+	assert.Equal(t, json.RawMessage(`{"id":"string","size":1}`), exampleOf(widget{}))
+	assert.Equal(t, json.RawMessage(`{"id":"string","size":1}`), exampleOf((*widget)(nil)))
+
+	s := new(Service).Path("/")
+	s.Route(s.POST("/widgets").To(SampleHandler).
+		Consumes("application/json").
+		Reads(widget{}).
+		Produces("application/json").
+		Writes([]widget{}))
+	buf := &bytes.Buffer{}
+	s.GenerateDocumentation(buf)
+	md := buf.String()
+	assert.Contains(t, md, `-d '{"id":"string","size":1}'`)
+	assert.JSONEq(t, `[{"id":"string","size":1}]`, string(s.JSONDoc().Routes[0].WriteSample))
+
+	// named types are linked to their model, with its fields listed once
+	assert.Contains(t, md, "_**Reads:**_ [`boneful.widget`](#model-boneful-widget)\n")
+	assert.Contains(t, md, "_**Writes:**_ `[]`[`boneful.widget`](#model-boneful-widget)\n")
+	assert.Contains(t, md, "<a id=\"model-boneful-widget\"></a>\n### `boneful.widget`\n")
+	assert.Contains(t, md, "`id` | `string` | required |  | \n`size` | `int` | required |  | \n")
+
+	// other types are shown as a synthesized example
+	s.Route(s.PUT("/widgets").To(SampleHandler).Operation("Replace").
+		Consumes("application/json").
+		Reads(struct {
+			Size int `json:"size"`
+		}{}))
+	buf.Reset()
+	s.GenerateDocumentation(buf)
+	assert.Contains(t, buf.String(), "_**Reads:**_\n```json\n        {\n          \"size\": 1\n        }\n```\n")
+}
+
+func TestNamedExamples(t *testing.T) {
+	s := new(Service).Path("/")
+	s.Route(s.POST("/search").To(SampleHandler).
+		Consumes("application/json").
+		Produces("application/json").
+		WritesExample(404, "missing", "", map[string]string{"error": "no such index"}).
+		ReadsExample("by-size", "", map[string]int{"size": 3}).
+		WritesExample(200, "found", "The matching widgets.", []widget{{ID: "w1", Size: 3}}).
+		ReadsExample("by-id", "Find one widget.", map[string]string{"id": "w1"}))
+	r := s.routes[0]
+
+	// the first examples stand in for Reads and Writes
+	assert.Equal(t, map[string]int{"size": 3}, r.ReadSample)
+	assert.Equal(t, []widget{{ID: "w1", Size: 3}}, r.WriteSample)
+	assert.Equal(t, "body", r.ParameterDocs[0].Data().Name)
+	assert.Equal(t, []string{"by-size", "by-id"}, exampleNames(r.ReadExamples()))
+	assert.Equal(t, []string{"found", "missing"}, exampleNames(r.WriteExamples()))
+
+	buf := &bytes.Buffer{}
+	s.GenerateDocumentation(buf)
+	md := buf.String()
+	assert.Contains(t, md, "#### Request example: by-id\n\nFind one widget.\n\n```json\n        {\n          \"id\": \"w1\"\n        }\n```\n")
+	assert.Contains(t, md, "#### Request example: by-size\n\n```json\n")
+	assert.Less(t, strings.Index(md, "example: by-size"), strings.Index(md, "example: by-id"))
+	assert.Contains(t, md, "#### Response example (200): found\n\nThe matching widgets.\n")
+	assert.Contains(t, md, "#### Response example (404): missing\n")
+	assert.Less(t, strings.Index(md, "(200): found"), strings.Index(md, "(404): missing"))
+
+	doc := s.JSONDoc()
+	jr := doc.Routes[0]
+	assert.Equal(t, JSONExample{Name: "by-id", Description: "Find one widget.", Value: json.RawMessage(`{"id":"w1"}`)}, jr.ReadExamples[1])
+	assert.JSONEq(t, `[{"id":"w1","size":3}]`, string(jr.WriteExamples[200][0].Value))
+	b, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"writeExamples":{"200":[{"name":"found","description":"The matching widgets.","value":[{"id":"w1","size":3}]}],"404":`)
+
+	ns, err := NewServiceFromJSONDoc(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"by-size", "by-id", "found", "missing"}, exampleNames(ns.routes[0].Examples))
+	assert.Equal(t, "Find one widget.", ns.routes[0].Examples[1].Description)
+	assert.Equal(t, 404, ns.routes[0].Examples[3].Status)
+
+	// Reads after ReadsExample replaces the sample, not the body parameter
+	s.Route(s.PUT("/search").To(SampleHandler).Operation("Replace").
+		ReadsExample("by-id", "", map[string]string{"id": "w1"}).
+		Reads(widget{}))
+	r = s.routes[1]
+	assert.Len(t, r.ParameterDocs, 1)
+	assert.Equal(t, "boneful.widget", r.ParameterDocs[0].Data().DataType)
+	assert.Equal(t, widget{}, r.ReadSample)
+
+	// names must be unique, per status for responses
+	assert.PanicsWithValue(t, `[boneful] "Request example: by-id" is already defined for route POST /search`, func() {
+		s.POST("/search").ReadsExample("by-id", "", widget{}).ReadsExample("by-id", "", widget{})
+	})
+	assert.NotPanics(t, func() {
+		s.POST("/search").WritesExample(200, "by-id", "", widget{}).WritesExample(404, "by-id", "", widget{})
+	})
+
+	// headings for examples are counted when working out anchors
+	s.Route(s.GET("/other").To(SampleHandler).Operation("Request example: by-id"))
+	assert.Equal(t, "request-example-by-id-2", s.Anchor(s.routes[2]))
+}
+
+func exampleNames(list []Example) []string {
+	names := []string{}
+	for _, e := range list {
+		names = append(names, e.Name)
+	}
+	return names
+}
@@ -0,0 +1,279 @@
+package boneful
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ExportOptions controls the output of GeneratePostman and GenerateHTTPFile.
+type ExportOptions struct {
+	// Name of the collection; the default is the service's root path.
+	Name string
+
+	// BaseURL is the initial value of the baseUrl variable that every
+	// request is relative to; the default is the first of the service's
+	// Servers, or http://localhost:8080.
+	BaseURL string
+}
+
+func (opts ExportOptions) withDefaults(s *Service) ExportOptions {
+	if opts.Name == "" {
+		opts.Name = s.RootPath()
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = s.exampleServer().Expand()
+	}
+	return opts
+}
+
+// exportGroup is the routes that share the first path segment after the
+// service's root path.
+type exportGroup struct {
+	name   string
+	routes []Route
+}
+
+func (s *Service) exportGroups() []exportGroup {
+	var groups []exportGroup
+	index := make(map[string]int)
+	for _, r := range s.Routes() {
+		rel := strings.TrimPrefix(strings.TrimPrefix(r.Path, strings.TrimRight(s.rootPath, "/")), "/")
+		name := strings.SplitN(rel, "/", 2)[0]
+		if name == "" || strings.ContainsAny(name[:1], ":#") {
+			name = strings.Trim(s.rootPath, "/")
+		}
+		if name == "" {
+			name = "/"
+		}
+		ix, ok := index[name]
+		if !ok {
+			ix = len(groups)
+			index[name] = ix
+			groups = append(groups, exportGroup{name: name})
+		}
+		groups[ix].routes = append(groups[ix].routes, r)
+	}
+	return groups
+}
+
+// exampleValue is the value an exported request starts with for a
+// parameter: its default, or else the first of its allowable values.
+func exampleValue(d ParameterData) string {
+	if d.DefaultValue != "" {
+		return d.DefaultValue
+	}
+	values := make([]string, 0, len(d.AllowableValues))
+	for v := range d.AllowableValues {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	if len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// exportPath rewrites the path variables of a bone path (":id", or
+// "#id^[0-9]+$" with a regular expression) with the result of fn.
+func exportPath(path string, fn func(name string) string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if m := pathVariable.FindStringSubmatch(seg); m != nil && m[0] == strings.SplitN(seg, "^", 2)[0] {
+			segments[i] = fn(m[1])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item,omitempty"`
+	Request *postmanRequest `json:"request,omitempty"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Description string            `json:"description,omitempty"`
+	Header      []postmanKeyValue `json:"header"`
+	Body        *postmanBody      `json:"body,omitempty"`
+	URL         postmanURL        `json:"url"`
+}
+
+type postmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw,omitempty"`
+	URLEncoded []postmanKeyValue `json:"urlencoded,omitempty"`
+	Options    interface{}       `json:"options,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+// GeneratePostman writes a Postman v2.1 collection with a request for each
+// route, in a folder for each group of routes (those sharing the first
+// segment of their path). Parameters are filled in from their default or
+// allowable values, optional ones without a default are disabled, and the
+// body is the ReadSample. URLs are relative to the {{baseUrl}} variable.
+func (s *Service) GeneratePostman(w io.Writer, opts ExportOptions) error {
+	opts = opts.withDefaults(s)
+	c := postmanCollection{
+		Info:     postmanInfo{Name: opts.Name, Description: s.documentation, Schema: postmanSchema},
+		Item:     []postmanItem{},
+		Variable: []postmanKeyValue{{Key: "baseUrl", Value: opts.BaseURL}},
+	}
+	for _, g := range s.exportGroups() {
+		folder := postmanItem{Name: g.name}
+		for _, r := range g.routes {
+			folder.Item = append(folder.Item, postmanItem{Name: r.Operation, Request: postmanRequestFor(r)})
+		}
+		c.Item = append(c.Item, folder)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+func postmanRequestFor(r Route) *postmanRequest {
+	req := requestBuilder{pathVar: func(d ParameterData) string { return ":" + d.Name }, indent: true}.build(r)
+	pr := &postmanRequest{
+		Method:      r.Method,
+		Description: strings.TrimSpace(r.Doc + "\n\n" + r.Notes),
+		Header:      []postmanKeyValue{},
+	}
+	pr.URL.Raw = "{{baseUrl}}" + req.target()
+	pr.URL.Host = []string{"{{baseUrl}}"}
+	pr.URL.Path = strings.Split(strings.TrimPrefix(req.path, "/"), "/")
+
+	// optional parameters without a value are included, but disabled
+	kvs := func(values []requestValue) []postmanKeyValue {
+		var list []postmanKeyValue
+		for _, v := range values {
+			list = append(list, postmanKeyValue{Key: v.Name, Value: v.value, Description: v.Description, Disabled: v.omitted})
+		}
+		return list
+	}
+	pr.URL.Variable = kvs(req.vars)
+	pr.URL.Query = kvs(req.query)
+	pr.Header = append(pr.Header, kvs(req.header)...)
+	if req.accept != "" {
+		pr.Header = append(pr.Header, postmanKeyValue{Key: "Accept", Value: req.accept})
+	}
+	if req.body != "" {
+		pr.Header = append(pr.Header, postmanKeyValue{Key: "Content-Type", Value: req.contentType})
+		pr.Body = &postmanBody{Mode: "raw", Raw: req.body}
+		if !isTextType(r.Consumes) {
+			pr.Body.Options = map[string]interface{}{"raw": map[string]string{"language": "json"}}
+		}
+	} else if len(req.form) > 0 {
+		pr.Body = &postmanBody{Mode: "urlencoded", URLEncoded: kvs(req.form)}
+	}
+	return pr
+}
+
+// GenerateHTTPFile writes the routes as a .http file, the request format
+// of the VS Code REST Client and of JetBrains IDEs, grouped as for
+// GeneratePostman. The base URL and the path variables are file variables
+// declared at the top, so that they only need to be set once.
+func (s *Service) GenerateHTTPFile(w io.Writer, opts ExportOptions) error {
+	opts = opts.withDefaults(s)
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# %s\n", opts.Name)
+	for _, line := range strings.Split(strings.TrimSpace(s.documentation), "\n") {
+		if line != "" {
+			fmt.Fprintf(b, "# %s\n", line)
+		}
+	}
+	fmt.Fprintf(b, "\n@baseUrl = %s\n", opts.BaseURL)
+
+	// path variables with the same name share a file variable, which
+	// starts with the first default given for it
+	vars := make(map[string]string)
+	var names []string
+	for _, r := range s.routes {
+		defaults := make(map[string]string)
+		for _, p := range r.ParameterDocs {
+			if d := p.Data(); d.Kind == PathParameterKind {
+				defaults[d.Name] = exampleValue(d)
+			}
+		}
+		for _, m := range pathVariable.FindAllStringSubmatch(r.Path, -1) {
+			v, seen := vars[m[1]]
+			if !seen {
+				names = append(names, m[1])
+			}
+			if v == "" {
+				vars[m[1]] = defaults[m[1]]
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(b, "@%s = %s\n", name, vars[name])
+	}
+
+	for _, g := range s.exportGroups() {
+		fmt.Fprintf(b, "\n\n# --- %s ---\n", g.name)
+		for _, r := range g.routes {
+			writeHTTPRequest(b, r)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeHTTPRequest(b *strings.Builder, r Route) {
+	fmt.Fprintf(b, "\n### %s\n", r.Operation)
+	for _, line := range strings.Split(strings.TrimSpace(r.Doc), "\n") {
+		if line != "" {
+			fmt.Fprintf(b, "# %s\n", line)
+		}
+	}
+
+	req := requestBuilder{pathVar: func(d ParameterData) string { return "{{" + d.Name + "}}" }, indent: true}.build(r)
+	fmt.Fprintf(b, "%s {{baseUrl}}%s\n", r.Method, req.target())
+	if req.accept != "" {
+		fmt.Fprintf(b, "Accept: %s\n", req.accept)
+	}
+	for _, h := range req.header {
+		// optional headers without a value are left commented out
+		prefix := ""
+		if h.omitted {
+			prefix = "# "
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", prefix, h.Name, h.value)
+	}
+	if req.body != "" {
+		fmt.Fprintf(b, "Content-Type: %s\n\n%s\n", req.contentType, req.body)
+	} else if form := req.formBody(); form != "" {
+		fmt.Fprintf(b, "Content-Type: %s\n\n%s\n", req.contentType, form)
+	}
+}
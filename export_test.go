@@ -0,0 +1,141 @@
+package boneful
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportService() *Service {
+	s := new(Service).Path("/api").Doc("Things and widgets")
+	s.Route(s.GET("/widgets/#id^[a-z0-9]+$").To(SampleHandler).
+		Operation("GetWidget").
+		Doc("Fetch a widget").
+		Param(PathParameter("id", "The widget's id").DefaultValue("w1")).
+		Param(QueryParameter("color", "Color").AllowableValues(map[string]string{"red": "", "blue": ""})).
+		Param(QueryParameter("limit", "Limit")).
+		Param(HeaderParameter("X-Trace", "Tracing id")).
+		Produces("application/json"))
+	s.Route(s.POST("/widgets").To(SampleHandler).
+		Operation("AddWidget").
+		Doc("Add a widget").
+		Consumes("application/json").
+		Reads(widget{ID: "w2", Size: 1}))
+	s.Route(s.POST("/things/:name").To(SampleHandler).
+		Operation("AddThing").
+		Param(FormParameter("size", "Size").Required(true).DefaultValue("3")))
+	return s
+}
+
+func TestGeneratePostman(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, exportService().GeneratePostman(buf, ExportOptions{BaseURL: "http://api.example.com"}))
+
+	var c postmanCollection
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &c))
+	assert.Equal(t, "/api", c.Info.Name)
+	assert.Equal(t, postmanSchema, c.Info.Schema)
+	assert.Equal(t, []postmanKeyValue{{Key: "baseUrl", Value: "http://api.example.com"}}, c.Variable)
+	assert.Len(t, c.Item, 2)
+	assert.Equal(t, "widgets", c.Item[0].Name)
+	assert.Equal(t, "things", c.Item[1].Name)
+
+	get := c.Item[0].Item[0].Request
+	assert.Equal(t, "{{baseUrl}}/api/widgets/:id?color=blue", get.URL.Raw)
+	assert.Equal(t, []string{"api", "widgets", ":id"}, get.URL.Path)
+	assert.Equal(t, []postmanKeyValue{{Key: "id", Value: "w1", Description: "The widget's id"}}, get.URL.Variable)
+	assert.Equal(t, []postmanKeyValue{
+		{Key: "color", Value: "blue", Description: "Color"},
+		{Key: "limit", Description: "Limit", Disabled: true},
+	}, get.URL.Query)
+	assert.Equal(t, []postmanKeyValue{
+		{Key: "X-Trace", Description: "Tracing id", Disabled: true},
+		{Key: "Accept", Value: "application/json"},
+	}, get.Header)
+
+	add := c.Item[0].Item[1].Request
+	assert.Equal(t, "raw", add.Body.Mode)
+	assert.JSONEq(t, `{"id":"w2","size":1}`, add.Body.Raw)
+
+	thing := c.Item[1].Item[0].Request
+	assert.Equal(t, []postmanKeyValue{{Key: "name"}}, thing.URL.Variable)
+	assert.Equal(t, "urlencoded", thing.Body.Mode)
+	assert.Equal(t, []postmanKeyValue{{Key: "size", Value: "3", Description: "Size"}}, thing.Body.URLEncoded)
+}
+
+func TestGenerateHTTPFile(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, exportService().GenerateHTTPFile(buf, ExportOptions{}))
+	assert.Equal(t, `# /api
+# Things and widgets
+
+@baseUrl = http://localhost:8080
+@id = w1
+@name = 
+
+
+# --- widgets ---
+
+### GetWidget
+# Fetch a widget
+GET {{baseUrl}}/api/widgets/{{id}}?color=blue
+Accept: application/json
+# X-Trace: 
+
+### AddWidget
+# Add a widget
+POST {{baseUrl}}/api/widgets
+Content-Type: application/json
+
+{
+  "id": "w2",
+  "size": 1
+}
+
+
+# --- things ---
+
+### AddThing
+POST {{baseUrl}}/api/things/{{name}}
+Content-Type: application/x-www-form-urlencoded
+
+size=3
+`, buf.String())
+}
+
+func TestCurlExample(t *testing.T) {
+	s := exportService().Servers(Server{Name: "staging", URL: "https://staging.example.com/"})
+	routes := s.Routes()
+	assert.Equal(t, `curl 'https://staging.example.com/api/widgets/w1?color=blue' \
+  -H 'Accept: application/json'`, s.CurlExample(routes[0]))
+	assert.Equal(t, `curl -X POST 'https://staging.example.com/api/widgets' \
+  -H 'Content-Type: application/json' \
+  -d '{"id":"w2","size":1}'`, s.CurlExample(routes[1]))
+	assert.Equal(t, `curl -X POST 'https://staging.example.com/api/things/<name>' \
+  --data-urlencode 'size=3'`, s.CurlExample(routes[2]))
+
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+
+	doc := s.JSONDoc()
+	assert.Equal(t, s.CurlExample(routes[2]), doc.Routes[0].CurlExample)
+}
+
+func TestExportEncodesValues(t *testing.T) {
+	s := new(Service).Path("/")
+	s.Route(s.POST("/search").To(SampleHandler).
+		Param(QueryParameter("q", "Query").DefaultValue("a b&c=#d")).
+		Param(FormParameter("note", "Note").DefaultValue("x&y z")))
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, s.GeneratePostman(buf, ExportOptions{}))
+	var c postmanCollection
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &c))
+	assert.Equal(t, "{{baseUrl}}/search?q=a+b%26c%3D%23d", c.Item[0].Item[0].Request.URL.Raw)
+
+	buf.Reset()
+	assert.Nil(t, s.GenerateHTTPFile(buf, ExportOptions{}))
+	assert.Contains(t, buf.String(), "POST {{baseUrl}}/search?q=a+b%26c%3D%23d\n")
+	assert.Contains(t, buf.String(), "\n\nnote=x%26y+z\n")
+}
@@ -0,0 +1,244 @@
+package boneful
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// FuzzRoutes runs a native Go fuzz test of the service's routes. Each input
+// picks a route and drives the synthesis of its path, query, header and
+// form values and its body from the route's documentation, sometimes
+// respecting the declared DataType, AllowableValues, Required and
+// AllowMultiple and sometimes deliberately violating them. The test fails
+// if a handler panics or answers with a 5xx status that isn't documented
+// with Returns. Call it from a fuzz test:
+//
+//	func FuzzAPI(f *testing.F) {
+//		boneful.FuzzRoutes(f, NewService())
+//	}
+//
+// and run it with go test -fuzz=FuzzAPI. Planned routes are skipped.
+func FuzzRoutes(f *testing.F, s *Service) {
+	routes := fuzzableRoutes(s)
+	if len(routes) == 0 {
+		f.Skip("no routes to fuzz")
+	}
+	mux := s.Mux()
+	for i := range routes {
+		f.Add(uint16(i), []byte{})
+		f.Add(uint16(i), []byte{0, 1, 2, 3, 4, 5, 6, 7})
+		f.Add(uint16(i), []byte{255, 254, 253, 252, 251, 250, 249, 248})
+	}
+	f.Fuzz(func(t *testing.T, route uint16, data []byte) {
+		r := routes[int(route)%len(routes)]
+		req, body := fuzzRequest(r, &fuzzSource{data: data})
+		if problem := fuzzProblem(mux, r, req, body); problem != "" {
+			t.Fatal(problem)
+		}
+	})
+}
+
+// CheckRoutes is a property-based version of FuzzRoutes for ordinary test
+// runs: it sends iterations pseudo-random requests to each route, and
+// reports the first failure for each route. The requests are the same
+// from run to run.
+func CheckRoutes(t *testing.T, s *Service, iterations int) bool {
+	t.Helper()
+	mux := s.Mux()
+	rnd := rand.New(rand.NewSource(1))
+	ok := true
+	for _, r := range fuzzableRoutes(s) {
+		for i := 0; i < iterations; i++ {
+			data := make([]byte, 32)
+			rnd.Read(data)
+			req, body := fuzzRequest(r, &fuzzSource{data: data})
+			if problem := fuzzProblem(mux, r, req, body); problem != "" {
+				t.Error(problem)
+				ok = false
+				break
+			}
+		}
+	}
+	return ok
+}
+
+func fuzzableRoutes(s *Service) []Route {
+	var routes []Route
+	for _, r := range s.routes {
+		if !r.Planned {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// fuzzProblem sends the request and describes what went wrong, if anything.
+func fuzzProblem(mux http.Handler, r Route, req *http.Request, body string) (problem string) {
+	describe := fmt.Sprintf("%s %s", req.Method, req.URL)
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		describe += fmt.Sprintf("\n%s: %s", k, strings.Join(req.Header[k], ", "))
+	}
+	if body != "" {
+		describe += fmt.Sprintf("\n\n%q", body)
+	}
+
+	w := httptest.NewRecorder()
+	defer func() {
+		if p := recover(); p != nil {
+			problem = fmt.Sprintf("%s: the handler panicked: %v\nrequest:\n%s", r, p, describe)
+		}
+	}()
+	mux.ServeHTTP(w, req)
+	if _, documented := r.ResponseErrors[w.Code]; w.Code >= 500 && !documented {
+		return fmt.Sprintf("%s: status %d (%q)\nrequest:\n%s", r, w.Code, strings.TrimSpace(w.Body.String()), describe)
+	}
+	return ""
+}
+
+// fuzzSource turns fuzz input into choices; when it runs out, every
+// choice is 0, which means a valid value.
+type fuzzSource struct {
+	data []byte
+	pos  int
+}
+
+func (src *fuzzSource) byte() byte {
+	if src.pos >= len(src.data) {
+		return 0
+	}
+	b := src.data[src.pos]
+	src.pos++
+	return b
+}
+
+func (src *fuzzSource) intn(n int) int {
+	return int(src.byte()) % n
+}
+
+// fuzzRequest synthesizes a request for a route, and returns its body too.
+func fuzzRequest(r Route, src *fuzzSource) (*http.Request, string) {
+	req := requestBuilder{
+		pathVar: func(d ParameterData) string {
+			v := fuzzValue(d, src)
+			if v == "" {
+				// an empty segment would just miss the route
+				v = "0"
+			}
+			return url.PathEscape(v)
+		},
+		values: func(d ParameterData) []string {
+			// usually the documented number of values, but sometimes none
+			// (even if required) or several (even if not allowed)
+			n := 1
+			switch src.intn(8) {
+			case 1:
+				n = 0
+			case 2:
+				n = 2 + src.intn(2)
+			}
+			if !d.Required && n == 1 && src.intn(2) == 1 {
+				n = 0
+			}
+			values := make([]string, n)
+			for i := range values {
+				values[i] = fuzzValue(d, src)
+				if d.Kind == HeaderParameterKind {
+					values[i] = strings.NewReplacer("\r", "", "\n", "").Replace(values[i])
+				}
+			}
+			return values
+		},
+		body: func(sample string) string { return fuzzBody(sample, src) },
+	}.build(r)
+	return req.httpRequest(), req.payload()
+}
+
+var fuzzEdgeStrings = []string{"", " ", "-1", "0", "null", "%00", "../..", "é世\U0001F600", "'\"<>&;", strings.Repeat("x", 4096)}
+
+// fuzzValue synthesizes a value for a parameter: valid for its DataType
+// and AllowableValues, or deliberately not.
+func fuzzValue(d ParameterData, src *fuzzSource) string {
+	switch src.intn(4) {
+	case 1:
+		return fuzzInvalid(d, src)
+	case 2:
+		return fuzzEdgeStrings[src.intn(len(fuzzEdgeStrings))]
+	case 3:
+		if d.DefaultValue != "" {
+			return d.DefaultValue
+		}
+	}
+	if len(d.AllowableValues) > 0 {
+		values := make([]string, 0, len(d.AllowableValues))
+		for v := range d.AllowableValues {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		return values[src.intn(len(values))]
+	}
+	n := int(int8(src.byte()))*int(src.byte()) + int(src.byte())
+	switch goParamType(d.DataType) {
+	case "int64":
+		return strconv.Itoa(n)
+	case "float64":
+		return strconv.FormatFloat(float64(n)/float64(1+src.intn(100)), 'g', -1, 64)
+	case "bool":
+		return strconv.FormatBool(src.intn(2) == 1)
+	}
+	b := make([]byte, src.intn(16))
+	for i := range b {
+		b[i] = 'a' + src.byte()%26
+	}
+	return string(b)
+}
+
+// fuzzInvalid synthesizes a value that doesn't fit the parameter's type.
+func fuzzInvalid(d ParameterData, src *fuzzSource) string {
+	if len(d.AllowableValues) > 0 {
+		return "not-allowed-" + strconv.Itoa(src.intn(100))
+	}
+	switch goParamType(d.DataType) {
+	case "int64":
+		return []string{"abc", "1.5", "99999999999999999999999", "0x1f", "1e3", "-"}[src.intn(6)]
+	case "float64":
+		return []string{"abc", "NaN", "Inf", "1e999", "1,5"}[src.intn(5)]
+	case "bool":
+		return []string{"maybe", "2", "yes", "TRUE "}[src.intn(4)]
+	}
+	return string([]byte{src.byte(), src.byte(), src.byte()})
+}
+
+// fuzzBody synthesizes a request body from the ReadSample: the sample, or
+// a broken or mistyped version of it.
+func fuzzBody(sample string, src *fuzzSource) string {
+	if sample == "" {
+		return ""
+	}
+	switch src.intn(6) {
+	case 1:
+		return ""
+	case 2:
+		return sample[:src.intn(len(sample))]
+	case 3:
+		return []string{"null", "[]", "{}", `"x"`, "0", "true"}[src.intn(6)]
+	case 4:
+		b := make([]byte, 1+src.intn(32))
+		for i := range b {
+			b[i] = src.byte()
+		}
+		return string(b)
+	}
+	return sample
+}
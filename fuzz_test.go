@@ -0,0 +1,63 @@
+package boneful
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// robustService validates its input, as every handler should.
+func robustService() *Service {
+	s := new(Service).Path("/api")
+	s.Route(s.GET("/widgets/:id").To(func(rw http.ResponseWriter, req *http.Request) {
+		if _, err := strconv.Atoi(req.URL.Query().Get("limit")); err != nil && req.URL.Query().Get("limit") != "" {
+			http.Error(rw, "bad limit", http.StatusBadRequest)
+		}
+	}).
+		Operation("GetWidget").
+		Param(PathParameter("id", "id")).
+		Param(QueryParameter("limit", "limit").DataType("integer")).
+		Param(QueryParameter("color", "color").AllowableValues(map[string]string{"red": "", "blue": ""})).
+		Returns(http.StatusBadRequest, "bad limit", nil))
+	return s
+}
+
+func FuzzRobustService(f *testing.F) {
+	FuzzRoutes(f, robustService())
+}
+
+func TestCheckRoutes(t *testing.T) {
+	assert.True(t, CheckRoutes(t, robustService(), 200))
+}
+
+func TestFuzzFindsProblems(t *testing.T) {
+	s := new(Service).Path("/api")
+	s.Route(s.GET("/widgets").To(func(rw http.ResponseWriter, req *http.Request) {
+		limits := map[string]int{"red": 1, "blue": 2}
+		n, err := strconv.Atoi(req.URL.Query().Get("limit"))
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sizes := []int{10, 20}
+		fmt.Fprint(rw, sizes[n%3+limits[req.URL.Query().Get("color")]]) // panics for some inputs
+	}).
+		Operation("ListWidgets").
+		Param(QueryParameter("limit", "limit").DataType("integer").Required(true)).
+		Param(QueryParameter("color", "color").AllowableValues(map[string]string{"red": "", "blue": ""})))
+
+	mux, r := s.Mux(), s.Routes()[0]
+	var errored, panicked bool
+	for i := 0; i < 256; i++ {
+		req, body := fuzzRequest(r, &fuzzSource{data: []byte{byte(i), byte(i * 7), byte(i * 13), byte(i * 31), 3, 1}})
+		p := fuzzProblem(mux, r, req, body)
+		errored = errored || strings.HasPrefix(p, "GET /api/widgets: status 500")
+		panicked = panicked || strings.HasPrefix(p, "GET /api/widgets: the handler panicked")
+	}
+	assert.True(t, errored)
+	assert.True(t, panicked)
+}
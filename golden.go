@@ -0,0 +1,238 @@
+package boneful
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateDocsEnv is the environment variable that, set to a non-empty
+// value, makes AssertDocsGolden write the golden files instead of
+// comparing them; an -update flag defined by the test binary does too.
+const UpdateDocsEnv = "BONEFUL_UPDATE_DOCS"
+
+// goldenExtensions maps the extension of a golden file to the format of
+// the documentation it holds.
+var goldenExtensions = map[string]string{
+	".md":       "markdown",
+	".markdown": "markdown",
+	".json":     "json",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".html":     "html",
+}
+
+// AssertDocsGolden compares the service's documentation with a checked-in
+// golden file, such as "testdata/api.md", and fails the test with a diff
+// if they differ, so that changes to the API can't go unnoticed. The
+// format is chosen by the file's extension (.md, .json, .yaml or .html,
+// or the name of a format added with RegisterDocRenderer), so call it
+// once for each format you want to keep.
+//
+// Run the tests with -update (if the test binary defines that flag, as
+// golden-file tests usually do) or with BONEFUL_UPDATE_DOCS=1 to write
+// the files instead. Source locations are left out of the snapshots,
+// since they change with unrelated edits.
+func AssertDocsGolden(t testing.TB, s *Service, path string) bool {
+	t.Helper()
+	ext := filepath.Ext(path)
+	format, ok := goldenExtensions[ext]
+	if !ok {
+		format = strings.TrimPrefix(ext, ".")
+	}
+	r := rendererFor(format, nil)
+	if r == nil {
+		t.Fatalf("[boneful] no documentation format for %s", path)
+		return false
+	}
+
+	buf := &bytes.Buffer{}
+	if err := r.Render(buf, s.withoutSources()); err != nil {
+		t.Fatalf("[boneful] rendering %s: %v", path, err)
+		return false
+	}
+	got := buf.String()
+
+	if updatingDocs() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("wrote %s", path)
+		return true
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("[boneful] %v; run the tests with -update or %s=1 to create it", err, UpdateDocsEnv)
+		return false
+	}
+	if string(want) != got {
+		t.Errorf("the documentation differs from %s (- golden, + current); if the change is intended, "+
+			"run the tests with -update or %s=1:\n%s", path, UpdateDocsEnv, lineDiff(string(want), got))
+		return false
+	}
+	return true
+}
+
+// formatExtensions is the extension WriteDocs uses for each format.
+var formatExtensions = map[string]string{
+	"markdown": ".md",
+	"json":     ".json",
+	"yaml":     ".yaml",
+	"html":     ".html",
+}
+
+// WriteDocs writes the documentation into dir, in a file called name
+// plus the usual extension for each of the formats, or for every
+// registered format if none are given. As with AssertDocsGolden, source
+// locations are left out, so that the files only change with the API.
+func (s *Service) WriteDocs(dir, name string, formats ...string) error {
+	if len(formats) == 0 {
+		for _, r := range DocRenderers() {
+			formats = append(formats, r.Format())
+		}
+	}
+	v := s.withoutSources()
+	for _, format := range formats {
+		r := rendererFor(format, nil)
+		if r == nil {
+			return fmt.Errorf("[boneful] unknown documentation format %q", format)
+		}
+		ext, ok := formatExtensions[format]
+		if !ok {
+			ext = "." + format
+		}
+		buf := &bytes.Buffer{}
+		if err := r.Render(buf, v); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+ext), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func updatingDocs() bool {
+	if os.Getenv(UpdateDocsEnv) != "" {
+		return true
+	}
+	if f := flag.Lookup("update"); f != nil {
+		if g, ok := f.Value.(flag.Getter); ok {
+			if b, ok := g.Get().(bool); ok {
+				return b
+			}
+		}
+	}
+	return false
+}
+
+// withoutSources returns a copy of s whose routes have no Source.
+func (s *Service) withoutSources() *Service {
+	v := s.withServers(s.servers)
+	v.routes = make([]Route, len(s.routes))
+	for i, r := range s.Routes() {
+		r.Source = nil
+		v.routes[i] = r
+	}
+	return v
+}
+
+// maxDiffLines bounds the work lineDiff does to align lines.
+const maxDiffLines = 2000
+
+// lineDiff describes how got differs from want, line by line, with a few
+// lines of context around each change.
+func lineDiff(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// the common prefix and suffix need no alignment
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	am, bm := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	if len(am) > maxDiffLines || len(bm) > maxDiffLines {
+		for _, l := range am {
+			lines = append(lines, line{'-', l})
+		}
+		for _, l := range bm {
+			lines = append(lines, line{'+', l})
+		}
+	} else {
+		// longest common subsequence of the middle parts
+		lcs := make([][]int, len(am)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(bm)+1)
+		}
+		for i := len(am) - 1; i >= 0; i-- {
+			for j := len(bm) - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(am) || j < len(bm) {
+			switch {
+			case i < len(am) && j < len(bm) && am[i] == bm[j]:
+				lines = append(lines, line{' ', am[i]})
+				i, j = i+1, j+1
+			case i < len(am) && (j == len(bm) || lcs[i+1][j] >= lcs[i][j+1]):
+				lines = append(lines, line{'-', am[i]})
+				i++
+			default:
+				lines = append(lines, line{'+', bm[j]})
+				j++
+			}
+		}
+	}
+
+	const context = 3
+	var all []line
+	for _, l := range a[:pre] {
+		all = append(all, line{' ', l})
+	}
+	all = append(all, lines...)
+	for _, l := range a[len(a)-suf:] {
+		all = append(all, line{' ', l})
+	}
+	out := &strings.Builder{}
+	lastShown := -1
+	for k, l := range all {
+		near := false
+		for d := -context; d <= context && !near; d++ {
+			near = k+d >= 0 && k+d < len(all) && all[k+d].op != ' '
+		}
+		if !near {
+			continue
+		}
+		if lastShown >= 0 && k > lastShown+1 {
+			out.WriteString("...\n")
+		}
+		fmt.Fprintf(out, "%c %s\n", l.op, l.text)
+		lastShown = k
+	}
+	return out.String()
+}
@@ -0,0 +1,329 @@
+package boneful
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// htmlRoute is the view of a Route used by the HTML console.
+type htmlRoute struct {
+	Route
+	Anchor      string
+	TryPath     string // the path, with its variables as {name}
+	ContentType string
+	ReadBody    string // the sample, to edit and send
+	Request     *htmlBody
+	Response    *htmlBody
+	Errors      []htmlError
+}
+
+type htmlError struct {
+	Code    int
+	Message string
+	Model   template.HTML
+}
+
+// htmlBody describes a request or response body by its type and, if it is
+// an object, its fields.
+type htmlBody struct {
+	Type   template.HTML
+	Fields []htmlField
+}
+
+// htmlModel is an entry of the Models section, as for Service.Models.
+type htmlModel struct {
+	Name   string
+	Anchor string
+	Fields []htmlField
+}
+
+type htmlField struct {
+	Name        string
+	Type        template.HTML
+	Required    bool
+	Description string
+	Enum        []string
+}
+
+// sampleJSON renders a sample payload for display or editing; strings are
+// shown as they are, since they are usually text bodies.
+func sampleJSON(sample interface{}) string {
+	if sample == nil {
+		return ""
+	}
+	if s, ok := sample.(string); ok {
+		return s
+	}
+	b, err := json.MarshalIndent(exampleOf(sample), "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// htmlType formats the Go type of a schema, linking to its model, as
+// modelType does for markdown.
+func htmlType(sc *Schema) template.HTML {
+	prefix, ref := goTypeOf(sc)
+	var h string
+	if prefix != "" {
+		h = "<code>" + template.HTMLEscapeString(prefix) + "</code>"
+	}
+	if ref != "" {
+		h += `<a href="#` + template.HTMLEscapeString(modelAnchor(ref)) + `"><code>` + template.HTMLEscapeString(ref) + "</code></a>"
+	}
+	return template.HTML(h)
+}
+
+// htmlFields lists the properties of an object schema, sorted by name.
+func htmlFields(sc *Schema) []htmlField {
+	props := make([]string, 0, len(sc.Properties))
+	for p := range sc.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+	fields := make([]htmlField, 0, len(props))
+	for _, p := range props {
+		ps := sc.Properties[p]
+		fields = append(fields, htmlField{
+			Name:        p,
+			Type:        htmlType(ps),
+			Required:    inEnum(sc.Required, p),
+			Description: ps.Description,
+			Enum:        ps.Enum,
+		})
+	}
+	return fields
+}
+
+// newHTMLBody describes a body; the fields of a model are listed with it.
+func newHTMLBody(sc *Schema, defs map[string]*Schema) *htmlBody {
+	if sc == nil {
+		return nil
+	}
+	b := &htmlBody{Type: htmlType(sc)}
+	if def, ok := defs[sc.RefName()]; ok && sc.Ref != "" {
+		sc = def
+	}
+	if sc.Properties != nil {
+		b.Fields = htmlFields(sc)
+	}
+	return b
+}
+
+func (s *Service) htmlRoutes() []htmlRoute {
+	defs := s.modelDefs()
+	routes := make([]htmlRoute, 0, len(s.routes))
+	for _, r := range s.Routes() {
+		hr := htmlRoute{
+			Route:    r,
+			Anchor:   s.Anchor(r),
+			TryPath:  exportPath(r.Path, func(name string) string { return "{" + name + "}" }),
+			ReadBody: sampleJSON(r.ReadSample),
+			Request:  newHTMLBody(sampleSchema(r.readSchema, r.ReadSample, defs), defs),
+			Response: newHTMLBody(sampleSchema(r.writeSchema, r.WriteSample, defs), defs),
+		}
+		if len(r.Consumes) > 0 {
+			hr.ContentType = r.Consumes[0]
+		}
+		for _, re := range r.ResponseErrors {
+			he := htmlError{Code: re.Code, Message: re.Message}
+			if sc := sampleSchema(re.schema, re.Model, defs); sc != nil {
+				he.Model = htmlType(sc)
+			}
+			hr.Errors = append(hr.Errors, he)
+		}
+		sort.Slice(hr.Errors, func(i, j int) bool { return hr.Errors[i].Code < hr.Errors[j].Code })
+		routes = append(routes, hr)
+	}
+	return routes
+}
+
+// htmlModels lists the models for the Models section.
+func (s *Service) htmlModels() []htmlModel {
+	defs := s.modelDefs()
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	models := make([]htmlModel, 0, len(names))
+	for _, name := range names {
+		models = append(models, htmlModel{Name: name, Anchor: modelAnchor(name), Fields: htmlFields(defs[name])})
+	}
+	return models
+}
+
+var htmlDocTemplate = template.Must(template.New("html").Parse(htmlTemplate))
+
+// GenerateHTMLDoc writes the interactive HTML console for the service.
+// The page is self-contained (it loads no external scripts or styles)
+// and its "try it" forms send requests to the origin that served it.
+func (s *Service) GenerateHTMLDoc(w io.Writer) {
+	htmlRenderer{}.Render(w, s)
+}
+
+func (s *Service) htmlData() map[string]interface{} {
+	return map[string]interface{}{
+		"RootPath":      s.RootPath(),
+		"Documentation": s.Documentation(),
+		"Routes":        s.htmlRoutes(),
+		"Models":        s.htmlModels(),
+	}
+}
+
+// GetDocHTML is a handler for the interactive HTML documentation.
+func (s *Service) GetDocHTML(rw http.ResponseWriter, req *http.Request) {
+	s.serveDoc(rw, req, rendererFor("html", htmlRenderer{}))
+}
+
+var htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.RootPath}} API</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0 auto; max-width: 60em; padding: 1em 2em; color: #24292e; }
+header p { white-space: pre-wrap; }
+details.route { border: 1px solid #d1d5da; border-radius: 4px; margin: 0.5em 0; }
+details.route > summary { cursor: pointer; padding: 0.5em 1em; font-family: monospace; font-size: 1.1em; }
+details.route > div { padding: 0 1em 1em; border-top: 1px solid #d1d5da; }
+.method { display: inline-block; min-width: 5em; font-weight: bold; }
+.GET { color: #0366d6; } .POST { color: #28a745; } .PUT, .PATCH { color: #b08800; } .DELETE { color: #cb2431; }
+.op { float: right; color: #6a737d; font-family: sans-serif; font-size: 0.9em; }
+.notes { white-space: pre-wrap; }
+.planned { color: #fff; background: #6a737d; border-radius: 3px; padding: 0 0.4em; font-size: 0.85em; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+th, td { border: 1px solid #d1d5da; padding: 0.25em 0.75em; text-align: left; vertical-align: top; }
+pre { background: #f6f8fa; padding: 0.75em; overflow: auto; }
+form.try label { display: block; margin: 0.25em 0; }
+form.try label span { display: inline-block; min-width: 10em; font-family: monospace; }
+form.try textarea { width: 100%; min-height: 8em; font-family: monospace; }
+form.try button { margin: 0.5em 0; }
+</style>
+</head>
+<body>
+<header>
+<h1><code>{{.RootPath}}</code></h1>
+<p>{{.Documentation}}</p>
+</header>
+{{range .Routes}}{{$r := .}}
+<details class="route" id="{{.Anchor}}">
+<summary><span class="method {{.Method}}">{{.Method}}</span> {{.Path}} <span class="op">{{.Operation}}{{if .Planned}} <span class="planned">planned</span>{{end}}</span></summary>
+<div>
+<p><em>{{.Doc}}</em></p>
+{{with .Notes}}<p class="notes">{{.}}</p>{{end}}
+{{if .ParameterDocs}}
+<h4>Parameters</h4>
+<table>
+<tr><th>Name</th><th>Kind</th><th>Description</th><th>DataType</th><th>Required</th><th>Default</th></tr>
+{{range .ParameterDocs}}{{with .Data}}<tr><td><code>{{.Name}}</code></td><td>{{.ParameterKind}}</td><td>{{.Description}}</td><td>{{.DataType}}</td><td>{{if .Required}}yes{{end}}</td><td>{{.DefaultValue}}</td></tr>
+{{end}}{{end}}</table>
+{{end}}
+{{if or .Request .Response .Errors}}
+<details class="schema">
+<summary>Schema</summary>
+{{with .Request}}<h4>Request{{with $r.ContentType}} ({{.}}){{end}}: {{.Type}}</h4>{{template "fields" .Fields}}{{end}}
+{{with .Response}}<h4>Response{{range $r.Produces}} ({{.}}){{end}}: {{.Type}}</h4>{{template "fields" .Fields}}{{end}}
+{{if .Errors}}<h4>Responses</h4>
+<table>
+<tr><th>Code</th><th>Meaning</th><th>Model</th></tr>
+{{range .Errors}}<tr><td>{{.Code}}</td><td>{{.Message}}</td><td>{{.Model}}</td></tr>
+{{end}}</table>
+{{end}}
+</details>
+{{end}}
+<h4>Try it</h4>
+<form class="try" data-method="{{.Method}}" data-path="{{.TryPath}}" data-content-type="{{.ContentType}}">
+{{$body := .ReadBody}}{{range .ParameterDocs}}{{with .Data}}{{if eq .ParameterKind "Body"}}<label><span>body</span></label>
+<textarea data-kind="Body" name="{{.Name}}">{{$body}}</textarea>
+{{else}}<label><span>{{.Name}}{{if .Required}}*{{end}}</span> <input data-kind="{{.ParameterKind}}" name="{{.Name}}" value="{{.DefaultValue}}" placeholder="{{.ParameterKind}} {{.DataType}}"{{if .Required}} required{{end}}></label>
+{{end}}{{end}}{{end}}<button type="submit">Send</button>
+<pre class="response" hidden></pre>
+</form>
+</div>
+</details>
+{{end}}
+{{with .Models}}
+<h2>Models</h2>
+{{range .}}<section class="model" id="{{.Anchor}}">
+<h3><code>{{.Name}}</code></h3>
+{{template "fields" .Fields}}
+</section>
+{{end}}{{end}}
+<script>
+(function () {
+	document.querySelectorAll("form.try").forEach(function (f) {
+		f.addEventListener("submit", function (ev) {
+			ev.preventDefault();
+			var path = f.dataset.path, query = [], form = [], headers = {}, body;
+			f.querySelectorAll("[data-kind]").forEach(function (el) {
+				var v = el.value;
+				if (v === "") {
+					return;
+				}
+				switch (el.dataset.kind) {
+				case "Path":
+					path = path.split("{" + el.name + "}").join(encodeURIComponent(v));
+					break;
+				case "Query":
+					query.push(encodeURIComponent(el.name) + "=" + encodeURIComponent(v));
+					break;
+				case "Header":
+					headers[el.name] = v;
+					break;
+				case "Form":
+					form.push(encodeURIComponent(el.name) + "=" + encodeURIComponent(v));
+					break;
+				case "Body":
+					body = v;
+					if (f.dataset.contentType) {
+						headers["Content-Type"] = f.dataset.contentType;
+					}
+					break;
+				}
+			});
+			if (body === undefined && form.length) {
+				body = form.join("&");
+				headers["Content-Type"] = "application/x-www-form-urlencoded";
+			}
+			if (query.length) {
+				path += "?" + query.join("&");
+			}
+			var out = f.querySelector(".response");
+			out.hidden = false;
+			out.textContent = f.dataset.method + " " + path + " ...";
+			fetch(path, { method: f.dataset.method, headers: headers, body: body, credentials: "same-origin" })
+				.then(function (resp) {
+					return resp.text().then(function (text) {
+						var lines = [resp.status + " " + resp.statusText];
+						resp.headers.forEach(function (v, k) {
+							lines.push(k + ": " + v);
+						});
+						var ct = resp.headers.get("Content-Type") || "";
+						if (ct.indexOf("json") >= 0) {
+							try {
+								text = JSON.stringify(JSON.parse(text), null, 2);
+							} catch (e) {}
+						}
+						out.textContent = lines.join("\n") + "\n\n" + text;
+					});
+				})
+				.catch(function (err) {
+					out.textContent = String(err);
+				});
+		});
+	});
+})();
+</script>
+</body>
+</html>
+{{define "fields"}}{{if .}}<table>
+<tr><th>Field</th><th>Type</th><th>Required</th><th>Description</th><th>Values</th></tr>
+{{range .}}<tr><td><code>{{.Name}}</code></td><td>{{.Type}}</td><td>{{if .Required}}required{{else}}optional{{end}}</td><td>{{.Description}}</td><td>{{range $i, $v := .Enum}}{{if $i}}, {{end}}<code>{{$v}}</code>{{end}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}`
@@ -0,0 +1,315 @@
+package boneful
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// JSONDocVersion is the version of the JSONDoc format. It changes
+// whenever the format changes in a way that would confuse older readers.
+const JSONDocVersion = "1.0"
+
+// JSONDoc is the self-describing documentation served by /jsondoc.
+// Routes are sorted by path and then method, and each route's responses
+// by status code, so that the output is stable from build to build.
+// Schemas is the JSON counterpart of the Models section of the markdown:
+// each named type used by the routes is described there once, with the
+// description and allowed values of its fields from their `doc` and
+// `enum` tags, and the routes refer to it by $ref.
+type JSONDoc struct {
+	Version string             `json:"version"`
+	Service JSONServiceInfo    `json:"service"`
+	Routes  []JSONRoute        `json:"routes"`
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// JSONServiceInfo describes the service as a whole.
+type JSONServiceInfo struct {
+	RootPath      string   `json:"rootPath"`
+	Documentation string   `json:"documentation"`
+	Servers       []Server `json:"servers,omitempty"`
+}
+
+// JSONRoute is the documentation of a single Route.
+type JSONRoute struct {
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Operation   string          `json:"operation"`
+	Doc         string          `json:"doc"`
+	Notes       string          `json:"notes,omitempty"`
+	Consumes    []string        `json:"consumes,omitempty"`
+	Produces    []string        `json:"produces,omitempty"`
+	Source      *SourceLocation `json:"source,omitempty"`
+	Planned     bool            `json:"planned,omitempty"`
+	Parameters  []JSONParameter `json:"parameters"`
+	ReadSample  json.RawMessage `json:"readSample,omitempty"`
+	ReadSchema  *Schema         `json:"readSchema,omitempty"`
+	WriteSample json.RawMessage `json:"writeSample,omitempty"`
+	WriteSchema *Schema         `json:"writeSchema,omitempty"`
+	Responses   []JSONResponse  `json:"responses,omitempty"`
+	CurlExample string          `json:"curlExample,omitempty"`
+
+	ReadExamples  []JSONExample         `json:"readExamples,omitempty"`
+	WriteExamples map[int][]JSONExample `json:"writeExamples,omitempty"` // by status
+}
+
+// JSONExample is a named example payload of a route.
+type JSONExample struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Value       json.RawMessage `json:"value"`
+}
+
+// JSONParameter is the documentation of a Parameter, with its kind
+// spelled out.
+type JSONParameter struct {
+	Name            string            `json:"name"`
+	Kind            string            `json:"kind"`
+	Description     string            `json:"description"`
+	DataType        string            `json:"datatype,omitempty"`
+	DataFormat      string            `json:"dataformat,omitempty"`
+	Required        bool              `json:"required"`
+	AllowableValues map[string]string `json:"allowablevalues,omitempty"`
+	AllowMultiple   bool              `json:"allowmultiple,omitempty"`
+	DefaultValue    string            `json:"defaultvalue,omitempty"`
+}
+
+// JSONResponse is the documentation of one response code.
+type JSONResponse struct {
+	Code        int             `json:"code"`
+	Message     string          `json:"message"`
+	ModelSample json.RawMessage `json:"modelSample,omitempty"`
+	ModelSchema *Schema         `json:"modelSchema,omitempty"`
+}
+
+// sampleRaw encodes a sample payload for the JSON documentation, with an
+// example synthesized from its type if it is empty.
+func sampleRaw(sample interface{}) json.RawMessage {
+	if sample == nil {
+		return nil
+	}
+	b, err := json.Marshal(exampleOf(sample))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// sampleValue is the inverse of sampleRaw: strings (text samples) become
+// strings again and everything else is kept as raw JSON.
+func sampleValue(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	var s string
+	if raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return raw
+}
+
+// sampleSchema returns the recorded schema of a sample that was read from
+// a JSONDoc, or derives one from the sample's Go type.
+func sampleSchema(recorded *Schema, sample interface{}, defs map[string]*Schema) *Schema {
+	if recorded != nil {
+		return recorded
+	}
+	return SchemaOf(sample, defs)
+}
+
+// JSONDoc builds the JSON documentation model of the service.
+func (s *Service) JSONDoc() *JSONDoc {
+	doc := &JSONDoc{
+		Version: JSONDocVersion,
+		Service: JSONServiceInfo{RootPath: s.rootPath, Documentation: s.documentation, Servers: s.servers},
+		Routes:  make([]JSONRoute, 0, len(s.routes)),
+		Schemas: make(map[string]*Schema),
+	}
+	for k, v := range s.schemas {
+		doc.Schemas[k] = v
+	}
+	for _, r := range s.Routes() {
+		jr := JSONRoute{
+			Method:      r.Method,
+			Path:        r.Path,
+			Operation:   r.Operation,
+			Doc:         r.Doc,
+			Notes:       r.Notes,
+			Consumes:    r.Consumes,
+			Produces:    r.Produces,
+			Source:      r.Source,
+			Planned:     r.Planned,
+			Parameters:  make([]JSONParameter, 0, len(r.ParameterDocs)),
+			ReadSample:  sampleRaw(r.ReadSample),
+			ReadSchema:  sampleSchema(r.readSchema, r.ReadSample, doc.Schemas),
+			WriteSample: sampleRaw(r.WriteSample),
+			WriteSchema: sampleSchema(r.writeSchema, r.WriteSample, doc.Schemas),
+			CurlExample: s.CurlExample(r),
+		}
+		for _, p := range r.ParameterDocs {
+			d := p.Data()
+			jr.Parameters = append(jr.Parameters, JSONParameter{
+				Name:            d.Name,
+				Kind:            strings.ToLower(d.ParameterKind()),
+				Description:     d.Description,
+				DataType:        d.DataType,
+				DataFormat:      d.DataFormat,
+				Required:        d.Required,
+				AllowableValues: d.AllowableValues,
+				AllowMultiple:   d.AllowMultiple,
+				DefaultValue:    d.DefaultValue,
+			})
+		}
+		for _, re := range r.ResponseErrors {
+			jr.Responses = append(jr.Responses, JSONResponse{
+				Code:        re.Code,
+				Message:     re.Message,
+				ModelSample: sampleRaw(re.Model),
+				ModelSchema: sampleSchema(re.schema, re.Model, doc.Schemas),
+			})
+		}
+		sort.Slice(jr.Responses, func(i, j int) bool { return jr.Responses[i].Code < jr.Responses[j].Code })
+		for _, e := range r.Examples {
+			je := JSONExample{Name: e.Name, Description: e.Description, Value: sampleRaw(e.Value)}
+			if e.Status == 0 {
+				jr.ReadExamples = append(jr.ReadExamples, je)
+				continue
+			}
+			if jr.WriteExamples == nil {
+				jr.WriteExamples = make(map[int][]JSONExample)
+			}
+			jr.WriteExamples[e.Status] = append(jr.WriteExamples[e.Status], je)
+		}
+		doc.Routes = append(doc.Routes, jr)
+	}
+	sort.SliceStable(doc.Routes, func(i, j int) bool {
+		if doc.Routes[i].Path != doc.Routes[j].Path {
+			return doc.Routes[i].Path < doc.Routes[j].Path
+		}
+		return doc.Routes[i].Method < doc.Routes[j].Method
+	})
+	if len(doc.Schemas) == 0 {
+		doc.Schemas = nil
+	}
+	return doc
+}
+
+var parameterKinds = map[string]int{
+	"path":   PathParameterKind,
+	"query":  QueryParameterKind,
+	"body":   BodyParameterKind,
+	"header": HeaderParameterKind,
+	"form":   FormParameterKind,
+}
+
+// NewServiceFromJSONDoc reconstructs a read-only Service model from its
+// JSON documentation. The routes have no handlers; their samples are the
+// raw JSON from the document, and their schemas are the documented ones.
+// It is meant for tools that work with documentation, not for serving.
+func NewServiceFromJSONDoc(doc *JSONDoc) (*Service, error) {
+	major := strings.SplitN(doc.Version, ".", 2)[0]
+	if major != strings.SplitN(JSONDocVersion, ".", 2)[0] {
+		return nil, fmt.Errorf("[boneful] unsupported JSON documentation version %q", doc.Version)
+	}
+	s := &Service{serviceConfig: serviceConfig{
+		rootPath:      doc.Service.RootPath,
+		documentation: doc.Service.Documentation,
+		schemas:       doc.Schemas,
+		servers:       doc.Service.Servers,
+	}}
+	for _, jr := range doc.Routes {
+		r := Route{
+			Method:         jr.Method,
+			Path:           jr.Path,
+			Operation:      jr.Operation,
+			Doc:            jr.Doc,
+			Notes:          jr.Notes,
+			Consumes:       jr.Consumes,
+			Produces:       jr.Produces,
+			Source:         jr.Source,
+			Planned:        jr.Planned,
+			ParameterDocs:  make([]*Parameter, 0, len(jr.Parameters)),
+			ResponseErrors: make(map[int]ResponseError),
+			ReadSample:     sampleValue(jr.ReadSample),
+			WriteSample:    sampleValue(jr.WriteSample),
+			readSchema:     jr.ReadSchema,
+			writeSchema:    jr.WriteSchema,
+		}
+		for _, jp := range jr.Parameters {
+			kind, ok := parameterKinds[strings.ToLower(jp.Kind)]
+			if !ok {
+				return nil, fmt.Errorf("[boneful] unknown kind %q for parameter %s of %s", jp.Kind, jp.Name, r)
+			}
+			r.ParameterDocs = append(r.ParameterDocs, &Parameter{&ParameterData{
+				Name:            jp.Name,
+				Description:     jp.Description,
+				DataType:        jp.DataType,
+				DataFormat:      jp.DataFormat,
+				Kind:            kind,
+				Required:        jp.Required,
+				AllowableValues: jp.AllowableValues,
+				AllowMultiple:   jp.AllowMultiple,
+				DefaultValue:    jp.DefaultValue,
+			}})
+		}
+		for _, resp := range jr.Responses {
+			r.ResponseErrors[resp.Code] = ResponseError{
+				Code:    resp.Code,
+				Message: resp.Message,
+				Model:   sampleValue(resp.ModelSample),
+				schema:  resp.ModelSchema,
+			}
+		}
+		r.Examples = examplesFromJSON(jr)
+		s.routes = append(s.routes, r)
+	}
+	return s, nil
+}
+
+// MarshalJSON encodes the service as its JSONDoc.
+func (s *Service) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.JSONDoc())
+}
+
+// UnmarshalJSON reads a JSONDoc (such as the output of /jsondoc) into s,
+// as described for NewServiceFromJSONDoc.
+func (s *Service) UnmarshalJSON(b []byte) error {
+	doc := &JSONDoc{}
+	if err := json.Unmarshal(b, doc); err != nil {
+		return err
+	}
+	ns, err := NewServiceFromJSONDoc(doc)
+	if err != nil {
+		return err
+	}
+	s.rootPath = ns.rootPath
+	s.documentation = ns.documentation
+	s.schemas = ns.schemas
+	s.servers = ns.servers
+	s.routes = ns.routes
+	s.cache.invalidate()
+	return nil
+}
+
+// examplesFromJSON lists a route's examples, in the order of the
+// document for each status.
+func examplesFromJSON(jr JSONRoute) []Example {
+	var list []Example
+	add := func(status int, examples []JSONExample) {
+		for _, je := range examples {
+			list = append(list, Example{Status: status, Name: je.Name, Description: je.Description, Value: sampleValue(je.Value)})
+		}
+	}
+	add(0, jr.ReadExamples)
+	statuses := make([]int, 0, len(jr.WriteExamples))
+	for status := range jr.WriteExamples {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		add(status, jr.WriteExamples[status])
+	}
+	return list
+}
@@ -0,0 +1,56 @@
+package boneful
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type node struct {
+	Name     string  `json:"name"`
+	Children []*node `json:"children,omitempty"`
+	secret   int
+}
+
+func TestSchemaOf(t *testing.T) {
+	defs := make(map[string]*Schema)
+	sc := SchemaOf([]node{}, defs)
+	assert.Equal(t, "array", sc.Type)
+	assert.Equal(t, "#/schemas/boneful.node", sc.Items.Ref)
+
+	n := defs["boneful.node"]
+	assert.Equal(t, "object", n.Type)
+	assert.Equal(t, []string{"name"}, n.Required)
+	assert.Equal(t, "string", n.Properties["name"].Type)
+	assert.Equal(t, "boneful.node", n.Properties["children"].Items.RefName())
+	assert.True(t, n.Properties["children"].Items.Nullable)
+	assert.Len(t, n.Properties, 2)
+}
+
+func TestJSONDocRoundTrip(t *testing.T) {
+	s := edgeCaseService()
+	b, err := json.Marshal(s)
+	assert.Nil(t, err)
+
+	doc := &JSONDoc{}
+	assert.Nil(t, json.Unmarshal(b, doc))
+	assert.Equal(t, JSONDocVersion, doc.Version)
+	assert.Equal(t, "/widgets", doc.Service.RootPath)
+	assert.Equal(t, "/widgets/", doc.Routes[0].Path)
+	assert.Equal(t, "DELETE", doc.Routes[1].Method)
+	assert.Equal(t, []int{400, 409}, []int{doc.Routes[0].Responses[0].Code, doc.Routes[0].Responses[1].Code})
+	assert.Equal(t, "header", doc.Routes[0].Parameters[0].Kind)
+	assert.JSONEq(t, `{"name":"root"}`, string(doc.Routes[0].ReadSample))
+	assert.Contains(t, doc.Schemas, "boneful.widget")
+
+	s2 := new(Service)
+	assert.Nil(t, json.Unmarshal(b, s2))
+	assert.Equal(t, "/widgets", s2.RootPath())
+	assert.Len(t, s2.Routes(), 4)
+	b2, err := json.Marshal(s2)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(b), string(b2))
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"version":"2.0"}`), s2))
+}
@@ -0,0 +1,192 @@
+package boneful
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Severity says how much a lint finding matters.
+type Severity int
+
+const (
+	// SeverityOff disables a lint rule
+	SeverityOff Severity = iota
+
+	// SeverityInfo findings are worth knowing about
+	SeverityInfo
+
+	// SeverityWarning findings should probably be fixed
+	SeverityWarning
+
+	// SeverityError findings must be fixed; AssertDocumented fails on them
+	SeverityError
+)
+
+func (sev Severity) String() string {
+	switch sev {
+	case SeverityOff:
+		return "off"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText lets severities appear by name in JSON.
+func (sev Severity) MarshalText() ([]byte, error) {
+	return []byte(sev.String()), nil
+}
+
+// LintRule names a documentation check performed by Service.Lint.
+type LintRule string
+
+const (
+	// LintEmptyDoc flags routes without a Doc
+	LintEmptyDoc LintRule = "empty-doc"
+
+	// LintMissingOperation flags routes without a meaningful Operation
+	// (including one derived from an anonymous handler)
+	LintMissingOperation LintRule = "missing-operation"
+
+	// LintNoReturns flags routes that document no Returns codes
+	LintNoReturns LintRule = "no-returns"
+
+	// LintNoReads flags POST and PUT routes that don't say what they Read
+	LintNoReads LintRule = "no-reads"
+
+	// LintProducesWithoutWrites flags routes that Produce a content type
+	// but don't say what they Write
+	LintProducesWithoutWrites LintRule = "produces-without-writes"
+
+	// LintParamDescription flags parameters without a description
+	LintParamDescription LintRule = "param-description"
+
+	// LintUndeclaredPathParam flags path variables without a matching
+	// PathParameter, and PathParameters that aren't in the path
+	LintUndeclaredPathParam LintRule = "undeclared-path-param"
+
+	// LintPlanned flags Planned routes, which have no handler yet
+	LintPlanned LintRule = "planned"
+)
+
+// lintAll marks a route on which every rule is suppressed.
+const lintAll LintRule = "*"
+
+// DefaultLintSeverities are the severities used for rules that haven't
+// been configured with Service.LintSeverity.
+var DefaultLintSeverities = map[LintRule]Severity{
+	LintEmptyDoc:              SeverityError,
+	LintMissingOperation:      SeverityWarning,
+	LintNoReturns:             SeverityWarning,
+	LintNoReads:               SeverityError,
+	LintProducesWithoutWrites: SeverityWarning,
+	LintParamDescription:      SeverityWarning,
+	LintUndeclaredPathParam:   SeverityError,
+	LintPlanned:               SeverityInfo,
+}
+
+// Finding is a problem with a route's documentation.
+type Finding struct {
+	Route    string   `json:"route"`
+	Rule     LintRule `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", f.Severity, f.Route, f.Message, f.Rule)
+}
+
+// LintSeverity changes the severity of a lint rule for this service;
+// use SeverityOff to disable it.
+func (s *Service) LintSeverity(rule LintRule, sev Severity) *Service {
+	if s.lintSeverities == nil {
+		s.lintSeverities = make(map[LintRule]Severity)
+	}
+	s.lintSeverities[rule] = sev
+	return s
+}
+
+func (s *Service) severity(rule LintRule) Severity {
+	if sev, ok := s.lintSeverities[rule]; ok {
+		return sev
+	}
+	return DefaultLintSeverities[rule]
+}
+
+var pathVariable = regexp.MustCompile(`[:#]([^/^]+)`)
+
+// Lint checks the documentation of every route and returns what it finds,
+// in route order. Rules can be configured with LintSeverity, and turned
+// off for individual routes with RouteBuilder.NoLint.
+func (s *Service) Lint() []Finding {
+	var findings []Finding
+	for _, r := range s.Routes() {
+		check := func(rule LintRule, failed bool, format string, args ...interface{}) {
+			if !failed || r.nolint[rule] || r.nolint[lintAll] {
+				return
+			}
+			sev := s.severity(rule)
+			if sev == SeverityOff {
+				return
+			}
+			findings = append(findings, Finding{r.String(), rule, sev, fmt.Sprintf(format, args...)})
+		}
+
+		check(LintPlanned, r.Planned, "not implemented yet")
+		check(LintEmptyDoc, strings.TrimSpace(r.Doc) == "", "no Doc")
+		anonName, anon := handlerName(r.Handler)
+		check(LintMissingOperation, r.Operation == "", "no Operation")
+		check(LintMissingOperation, anon && r.Operation == anonName,
+			"Operation %q was derived from an anonymous function", r.Operation)
+		check(LintNoReturns, len(r.ResponseErrors) == 0, "no Returns")
+		check(LintNoReads, (r.Method == "POST" || r.Method == "PUT") && r.ReadSample == nil,
+			"%s without Reads", r.Method)
+		check(LintProducesWithoutWrites, len(r.Produces) > 0 && r.WriteSample == nil,
+			"Produces %s without Writes", strings.Join(r.Produces, ", "))
+
+		declared := make(map[string]bool)
+		for _, p := range r.ParameterDocs {
+			d := p.Data()
+			check(LintParamDescription, d.Kind != BodyParameterKind && strings.TrimSpace(d.Description) == "",
+				"%s parameter %s has no description", strings.ToLower(d.ParameterKind()), d.Name)
+			if d.Kind == PathParameterKind {
+				declared[d.Name] = true
+			}
+		}
+		inPath := make(map[string]bool)
+		for _, m := range pathVariable.FindAllStringSubmatch(r.Path, -1) {
+			inPath[m[1]] = true
+			check(LintUndeclaredPathParam, !declared[m[1]], "path variable %s has no PathParameter", m[1])
+		}
+		for _, p := range r.ParameterDocs {
+			d := p.Data()
+			check(LintUndeclaredPathParam, d.Kind == PathParameterKind && !inPath[d.Name],
+				"PathParameter %s is not in the path", d.Name)
+		}
+	}
+	return findings
+}
+
+// AssertDocumented lints the service's documentation as part of a test.
+// Findings with SeverityError fail the test; others are logged.
+func AssertDocumented(t testing.TB, s *Service) bool {
+	t.Helper()
+	ok := true
+	for _, f := range s.Lint() {
+		if f.Severity >= SeverityError {
+			t.Error(f)
+			ok = false
+		} else {
+			t.Log(f)
+		}
+	}
+	return ok
+}
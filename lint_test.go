@@ -0,0 +1,67 @@
+package boneful
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	s := new(Service).Path("/api")
+	s.Route(s.POST("/things/:id").To(SampleHandler).
+		Param(QueryParameter("q", "")).
+		Param(PathParameter("name", "Not in the path")).
+		Produces("application/json"))
+	s.Route(s.GET("/ok/:id").To(SampleHandler).
+		Operation("Fine").
+		Doc("Fine").
+		Param(PathParameter("id", "The id")).
+		Returns(http.StatusNotFound, "not found", nil))
+	s.Route(s.GET("/quiet").To(SampleHandler).
+		Operation("Quiet").
+		NoLint())
+	s.Route(s.DELETE("/hush").To(SampleHandler).
+		Operation("Hush").
+		Doc("Hush").
+		NoLint(LintNoReturns))
+
+	var got []string
+	for _, f := range s.Lint() {
+		got = append(got, f.String())
+	}
+	assert.Equal(t, []string{
+		"error: POST /api/things/:id: no Doc [empty-doc]",
+		"warning: POST /api/things/:id: no Returns [no-returns]",
+		"error: POST /api/things/:id: POST without Reads [no-reads]",
+		"warning: POST /api/things/:id: Produces application/json without Writes [produces-without-writes]",
+		"warning: POST /api/things/:id: query parameter q has no description [param-description]",
+		"error: POST /api/things/:id: path variable id has no PathParameter [undeclared-path-param]",
+		"error: POST /api/things/:id: PathParameter name is not in the path [undeclared-path-param]",
+	}, got)
+	ft := &fakeTB{TB: t}
+	assert.False(t, AssertDocumented(ft, s))
+	assert.Len(t, ft.errors, 4)
+
+	s.LintSeverity(LintEmptyDoc, SeverityOff).
+		LintSeverity(LintNoReads, SeverityInfo).
+		LintSeverity(LintUndeclaredPathParam, SeverityWarning)
+	assert.True(t, AssertDocumented(t, s))
+	got = nil
+	for _, f := range s.Lint() {
+		got = append(got, f.String())
+	}
+	assert.Equal(t, []string{
+		"warning: POST /api/things/:id: no Returns [no-returns]",
+		"info: POST /api/things/:id: POST without Reads [no-reads]",
+		"warning: POST /api/things/:id: Produces application/json without Writes [produces-without-writes]",
+		"warning: POST /api/things/:id: query parameter q has no description [param-description]",
+		"warning: POST /api/things/:id: path variable id has no PathParameter [undeclared-path-param]",
+		"warning: POST /api/things/:id: PathParameter name is not in the path [undeclared-path-param]",
+	}, got)
+
+	anon := new(Service)
+	anon.Route(anon.GET("/").To(func(rw http.ResponseWriter, req *http.Request) {}).Doc("x"))
+	findings := anon.Lint()
+	assert.Equal(t, LintMissingOperation, findings[0].Rule)
+}
@@ -0,0 +1,100 @@
+package boneful
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// update is read by AssertDocsGolden
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+type widget struct {
+	ID   string `json:"id"`
+	Size int    `json:"size"`
+}
+
+func edgeCaseService() *Service {
+	s := new(Service).Path("/widgets").
+		Doc(`Widgets with awkward names.`)
+
+	s.Route(s.GET("/:id").To(SampleHandler).
+		Operation("Get Widget (by ID)").
+		Doc(`Fetch a widget`).
+		Param(PathParameter("id", "The widget's id | or its alias\nbut never both").DataType("string")).
+		Produces("application/json").
+		Writes(widget{ID: "w1", Size: 3}).
+		Returns(http.StatusNotFound, "no such widget | alias", nil))
+
+	s.Route(s.PUT("/:id").To(SampleHandler).
+		Operation("get-widget-by-id").
+		Doc(`Replace a widget; its slug collides with the one above`).
+		Param(PathParameter("id", "The widget's id")).
+		Consumes("application/json").
+		Reads(widget{ID: "w1", Size: 4}).
+		Produces("text/plain").
+		Writes("replaced"))
+
+	s.Route(s.DELETE("/:id").To(SampleHandler).
+		Operation("Widgets").
+		Doc(`Collides with the service heading`))
+
+	s.Route(s.POST("/").To(SampleHandler).
+		Operation("Add").
+		Doc(`Add a tree of widgets`).
+		Param(HeaderParameter("X-Trace", "Tracing id")).
+		Param(HeaderParameter("X-Tag", "Tags").AllowMultiple(true)).
+		Param(QueryParameter("color", "Color").AllowableValues(map[string]string{"red": "", "blue": ""})).
+		Param(QueryParameter("limit", "Limit").DataType("integer").Required(true)).
+		Consumes("application/json").
+		Reads(node{Name: "root"}).
+		Produces("application/json").
+		Writes([]widget{}).
+		Returns(http.StatusConflict, "exists", widget{ID: "w1"}).
+		Returns(http.StatusBadRequest, "bad", nil))
+
+	return s
+}
+
+func TestMarkdownGolden(t *testing.T) {
+	AssertDocsGolden(t, edgeCaseService(), "testdata/edgecases.md")
+}
+
+func TestSlugs(t *testing.T) {
+	assert.Equal(t, "get-widget-by-id", slugify("Get Widget (by ID)"))
+	assert.Equal(t, "get-widgetsid", slugify("`GET /widgets/:id`"))
+
+	s := edgeCaseService()
+	routes := s.Routes()
+	assert.Equal(t, "get-widget-by-id", s.Anchor(routes[0]))
+	assert.Equal(t, "get-widget-by-id-1", s.Anchor(routes[1]))
+	assert.Equal(t, "widgets-1", s.Anchor(routes[2]))
+}
+
+func TestLineDiff(t *testing.T) {
+	want := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	got := "a\nb\nc\nd\nE\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	assert.Equal(t, "  b\n  c\n  d\n- e\n+ E\n  f\n  g\n  h\n...\n  k\n  l\n  m\n+ n\n  \n", lineDiff(want, got))
+
+	ft := &fakeTB{TB: t}
+	s := edgeCaseService()
+	assert.False(t, AssertDocsGolden(ft, s.Doc("Changed"), "testdata/edgecases.md"))
+	assert.Contains(t, ft.errors[0], "- Widgets with awkward names.\n+ Changed\n")
+}
+
+// fakeTB records the errors of a test that is meant to fail.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Error(args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprint(args...))
+}
@@ -1,24 +1,204 @@
 package boneful
 
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs returns the functions available to documentation templates.
+// It is used for the built-in template and for any template passed to
+// DocTemplate or DocTemplateFS; if you parse your own template, add these
+// with Funcs before parsing it.
+//
+//	lower       converts a string to lower case
+//	slugify     converts a heading to a GitHub-style anchor
+//	join        joins a []string with a separator: {{join ", " .Consumes}}
+//	code        wraps a string in markdown backticks
+//	json        formats a value as indented JSON
+//	statusText  gives the text for an HTTP status code, like "Not Found"
+//	escape      makes a string safe for use in a markdown table cell
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"lower":      strings.ToLower,
+		"slugify":    slugify,
+		"join":       func(sep string, a []string) string { return strings.Join(a, sep) },
+		"code":       codeSpan,
+		"json":       indentJSON,
+		"statusText": http.StatusText,
+		"escape":     escapeTableCell,
+	}
+}
+
+var slugDrop = regexp.MustCompile(`[^\p{L}\p{N}\p{M}_\- ]`)
+
+// slugify produces the anchor that GitHub generates for a heading.
+func slugify(heading string) string {
+	s := strings.ToLower(strings.TrimSpace(heading))
+	s = slugDrop.ReplaceAllString(s, "")
+	return strings.Replace(s, " ", "-", -1)
+}
+
+// slugger hands out heading anchors, numbering repeats the way GitHub
+// does: "name", "name-1", "name-2" and so on.
+type slugger map[string]int
+
+func (sl slugger) slug(heading string) string {
+	base := slugify(heading)
+	s := base
+	if _, used := sl[s]; used {
+		n := sl[base]
+		for {
+			n++
+			s = fmt.Sprintf("%s-%d", base, n)
+			if _, used := sl[s]; !used {
+				break
+			}
+		}
+		sl[base] = n
+	}
+	sl[s] = 0
+	return s
+}
+
+// Anchor returns the anchor of the given route's heading in the markdown
+// produced by the built-in template. It accounts for every heading that
+// precedes it, so routes whose operations differ only in punctuation or
+// case still get distinct, working links.
+func (s *Service) Anchor(r Route) string {
+	sl := slugger{}
+	sl.slug(s.RootPath())
+	for _, rt := range s.routes {
+		a := sl.slug(rt.Operation)
+		if rt.Operation == r.Operation && rt.String() == r.String() {
+			return a
+		}
+		sl.slug(rt.String())
+		for _, e := range rt.Examples {
+			sl.slug(e.Heading())
+		}
+	}
+	return slugify(r.Operation)
+}
+
+// codeSpan wraps s in enough backticks to survive any that it contains.
+func codeSpan(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+func indentJSON(v interface{}) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// escapeTableCell keeps pipes and line breaks from breaking table rows.
+func escapeTableCell(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	return strings.Replace(strings.TrimSpace(s), "\n", "<br>", -1)
+}
+
+// DocTemplate replaces the template used to generate the markdown
+// documentation. The template is executed with the *Service as its data,
+// so it has access to
+//
+//	.RootPath              the service's root path
+//	.Documentation         the service's Doc text
+//	.Routes                a []Route, each with its fields (Method, Path, Doc,
+//	                       Notes, Operation, Consumes, Produces, ParameterDocs,
+//	                       ResponseErrors, Source) and the Reads, Writes,
+//	                       ReadFormat and WriteFormat methods, and Examples
+//	                       with the ExampleFormat and FormatExample methods
+//	$.SourceLink <route>   the URL of a route's handler source (see SourceURL)
+//	$.Anchor <route>       the anchor of a route's heading in the built-in layout
+//	.Models                the []Model that the routes read, write and return
+//	$.ReadModel <route>    a link to the model of a route's Reads sample, if any;
+//	                       likewise $.WriteModel <route> and
+//	                       $.ResponseModel <response error>
+//
+// along with the functions described under TemplateFuncs. Those functions
+// are added to t, but they must also be added before it is parsed if it
+// refers to any of them.
+func (s *Service) DocTemplate(t *template.Template) *Service {
+	s.docTemplate = t.Funcs(TemplateFuncs())
+	s.cache.invalidate()
+	return s
+}
+
+// DocTemplateFS parses the named files or glob patterns from fsys and uses
+// the result as the documentation template, as for DocTemplate. The first
+// file matched is the one that is executed; others can hold definitions
+// it uses. It panics if the templates can't be parsed.
+func (s *Service) DocTemplateFS(fsys fs.FS, patterns ...string) *Service {
+	var name string
+	for _, p := range patterns {
+		matches, err := fs.Glob(fsys, p)
+		if err == nil && len(matches) > 0 {
+			name = matches[0]
+			break
+		}
+	}
+	if name == "" {
+		panic("[boneful] No documentation template found matching " + strings.Join(patterns, ", "))
+	}
+	t := template.New(path.Base(name)).Funcs(TemplateFuncs())
+	s.docTemplate = template.Must(t.ParseFS(fsys, patterns...))
+	s.cache.invalidate()
+	return s
+}
+
 var mdTemplate = `
 ---
 # ` + "`" + `{{.RootPath}}` + "`" + `
 
-{{.Documentation}}
+{{.Documentation}}{{with .ServerList}}
 
+_**Servers:**_
 
-{{range .Routes}}
-* [{{.Operation}}](#{{lower .Operation}})
+Name | URL | Description
+---- | --- | -----------
+{{range . -}}
+{{escape .Name}} | {{code .URL}} | {{escape .Description}}
 {{end}}
+{{- range . -}}
+{{$srv := .}}{{range $name := .VariableNames}}{{with index $srv.Variables $name}}
+* {{code (print "{" $name "}")}} in {{$srv.Name}}: default {{code .Default}}
+{{- with .Enum}}, one of {{code (join ", " .)}}{{end}}{{with .Description}} -- {{.}}{{end}}{{end}}{{end}}
+{{- end}}{{end}}
+
+
+{{range .Routes}}
+* [{{.Operation}}](#{{$.Anchor .}}){{if .Planned}} (planned){{end}}
+{{- end}}
 
 
 {{range .Routes}}
 ---
 ## {{.Operation}}
 
-### ` + "`" + `{{.Method}} {{.Path}}` + "`" + `
-
+### {{code (print .Method " " .Path)}}
+{{if .Planned}}
+> **Planned:** this route is documented but not implemented yet.
+{{end}}
 _{{.Doc}}_
+{{with $.SourceLink .}}
+[Source]({{.}})
+{{end}}
 
 {{.Notes}}
 
@@ -27,35 +207,64 @@ _**Parameters:**_
 
 Name | Kind | Description | DataType
 ---- | ---- | ----------- | --------
-{{range .ParameterDocs}} {{.Data.Name}} | {{.Data.ParameterKind}} | {{.Data.Description}} | {{.Data.DataType}}
+{{range .ParameterDocs -}}
+{{escape .Data.Name}} | {{.Data.ParameterKind}} | {{escape .Data.Description}} | {{escape .Data.DataType}}
 {{end}}
 {{end}}
 
 {{if .Consumes}}
-_**Consumes:**_ ` + "`" + `{{.Consumes}}` + "`" + `
+_**Consumes:**_ {{code (join ", " .Consumes)}}
 {{end}}
 {{if .Reads}}
-_**Reads:**_
-` + "```{{.CodeFormat}}" + `
+_**Reads:**_{{with $.ReadModel .}} {{.}}{{else}}
+` + "```{{.ReadFormat}}" + `
         {{.Reads}}
-` + "```" + `
+` + "```" + `{{end}}
 {{end}}
 {{if .Produces}}
-_**Produces:**_ ` + "`" + `{{.Produces}}` + "`" + `
+_**Produces:**_ {{code (join ", " .Produces)}}
 {{end}}
 {{if .Writes}}
-_**Writes:**_
-` + "```{{.CodeFormat}}" + `
+_**Writes:**_{{with $.WriteModel .}} {{.}}{{else}}
+` + "```{{.WriteFormat}}" + `
         {{.Writes}}
+` + "```" + `{{end}}
+{{end}}{{$r := .}}{{range .Examples}}
+#### {{.Heading}}
+{{with .Description}}
+{{.}}
+{{end}}
+` + "```{{$r.ExampleFormat .}}" + `
+        {{$r.FormatExample .}}
 ` + "```" + `
 {{end}}
+_**Example:**_
+` + "```sh" + `
+{{$.CurlExample .}}
+` + "```" + `
+
 {{if .ResponseErrors}}
 _**Error returns:**_
 
 Code | Meaning
 ---- | --------
-{{range .ResponseErrors}} {{.Code}} | {{.Message}}
+{{range .ResponseErrors -}}
+{{.Code}} | {{escape .Message}}{{with $.ResponseModel .}} ({{.}}){{end}}
 {{end}}
 {{end}}
 {{end}}
+{{with .Models}}
+---
+## Models
+{{range .}}
+<a id="{{.Anchor}}"></a>
+### {{code .Name}}
+{{if .Fields}}
+Field | Type | Required | Description | Values
+----- | ---- | -------- | ----------- | ------
+{{range .Fields -}}
+{{code .Name}} | {{.Type}} | {{if .Required}}required{{else}}optional{{end}} | {{escape .Description}} | {{with .Enum}}{{code (join ", " .)}}{{end}}
+{{end}}{{else}}
+_No fields._
+{{end}}{{end}}{{end}}
 `
@@ -0,0 +1,106 @@
+package boneful
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-zoo/bone"
+)
+
+// MockStatusHeader is the request header that selects which documented
+// response a mock route returns, as in "X-Mock-Status: 404".
+const MockStatusHeader = "X-Mock-Status"
+
+// MockMux returns a multiplexer like Mux, except that every route is
+// served by a mock built from its documentation instead of its handler:
+// the response is the WriteSample, with the first content type the route
+// Produces. A request can ask for any of the codes documented with
+// Returns by setting MockStatusHeader; the body is then the model given
+// for that code, or its message if there is none. The documentation
+// endpoints are served as usual.
+//
+// Since it doesn't need the handlers, MockMux also works for a Service
+// read from a JSONDoc; see the bonemock command.
+func (s *Service) MockMux() *bone.Mux {
+	return s.mux(mockHandler)
+}
+
+func mockHandler(r Route) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		code, body := mockSuccess(r)
+		if h := req.Header.Get(MockStatusHeader); h != "" {
+			n, err := strconv.Atoi(strings.TrimSpace(h))
+			re, ok := r.ResponseErrors[n]
+			if err != nil || !ok {
+				http.Error(rw, fmt.Sprintf("%s %q is not documented for %s; use one of %s",
+					MockStatusHeader, h, r, documentedCodes(r)), http.StatusBadRequest)
+				return
+			}
+			code, body = n, re.Model
+			if body == nil && !isSuccess(n) {
+				http.Error(rw, re.Message, n)
+				return
+			}
+		}
+		writeMock(rw, r.Produces, code, body)
+	}
+}
+
+// mockSuccess picks the lowest documented 2xx code, or 200, and its body.
+func mockSuccess(r Route) (int, interface{}) {
+	codes := make([]int, 0, len(r.ResponseErrors))
+	for code := range r.ResponseErrors {
+		if isSuccess(code) {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return http.StatusOK, r.WriteSample
+	}
+	sort.Ints(codes)
+	if model := r.ResponseErrors[codes[0]].Model; model != nil {
+		return codes[0], model
+	}
+	return codes[0], r.WriteSample
+}
+
+func documentedCodes(r Route) string {
+	codes := make([]string, 0, len(r.ResponseErrors))
+	for code := range r.ResponseErrors {
+		codes = append(codes, strconv.Itoa(code))
+	}
+	sort.Strings(codes)
+	if len(codes) == 0 {
+		return "none (no Returns are documented)"
+	}
+	return strings.Join(codes, ", ")
+}
+
+// writeMock writes a sample as the content type, writing strings as they
+// are for non-JSON types and everything else as JSON.
+func writeMock(rw http.ResponseWriter, produces []string, code int, body interface{}) {
+	contentType := firstOr(produces, "application/json")
+	if body == nil {
+		rw.WriteHeader(code)
+		return
+	}
+	if s, ok := body.(string); ok && !strings.Contains(contentType, "json") {
+		rw.Header().Set("Content-Type", contentType)
+		rw.WriteHeader(code)
+		io.WriteString(rw, s)
+		return
+	}
+	b, err := json.Marshal(exampleOf(body))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("[boneful] can't encode the sample: %v", err), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", contentType)
+	rw.WriteHeader(code)
+	rw.Write(b)
+}
@@ -0,0 +1,65 @@
+package boneful
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockRequest(s *Service, method, url, status string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, nil)
+	if status != "" {
+		req.Header.Set(MockStatusHeader, status)
+	}
+	w := httptest.NewRecorder()
+	s.MockMux().ServeHTTP(w, req)
+	return w
+}
+
+func TestMockMux(t *testing.T) {
+	s := edgeCaseService()
+	s.Route(s.POST("/drafts").To(SampleHandler).
+		Operation("Draft").
+		Produces("application/json").
+		Writes(widget{ID: "w2"}).
+		Returns(http.StatusCreated, "created", nil))
+
+	w := mockRequest(s, "GET", "/widgets/w9", "")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"id":"w1","size":3}`, w.Body.String())
+
+	w = mockRequest(s, "GET", "/widgets/w9", "404")
+	assert.Equal(t, 404, w.Code)
+	assert.Contains(t, w.Body.String(), "no such widget | alias")
+
+	w = mockRequest(s, "GET", "/widgets/w9", "500")
+	assert.Equal(t, 400, w.Code)
+	assert.Contains(t, w.Body.String(), "use one of 404")
+
+	w = mockRequest(s, "PUT", "/widgets/w9", "")
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "replaced", w.Body.String())
+
+	w = mockRequest(s, "POST", "/widgets/drafts", "")
+	assert.Equal(t, 201, w.Code)
+	assert.JSONEq(t, `{"id":"w2","size":0}`, w.Body.String())
+
+	w = mockRequest(s, "POST", "/widgets/", "409")
+	assert.Equal(t, 409, w.Code)
+	assert.JSONEq(t, `{"id":"w1","size":0}`, w.Body.String())
+
+	// and from the JSON documentation, without any handlers
+	doc, err := json.Marshal(s)
+	assert.Nil(t, err)
+	js := new(Service)
+	assert.Nil(t, json.Unmarshal(doc, js))
+	w = mockRequest(js, "POST", "/widgets/", "409")
+	assert.Equal(t, 409, w.Code)
+	assert.JSONEq(t, `{"id":"w1","size":0}`, w.Body.String())
+	w = mockRequest(js, "PUT", "/widgets/w9", "")
+	assert.Equal(t, "replaced", w.Body.String())
+}
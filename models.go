@@ -0,0 +1,171 @@
+package boneful
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Model is a named type that the routes read, write or return, as listed
+// in the Models section of the markdown documentation.
+type Model struct {
+	Name   string // the qualified Go name, like "api.Widget"
+	Anchor string // the id of the model's entry in the markdown
+	Fields []ModelField
+}
+
+// ModelField is a property of a Model. Its Description and Enum come from
+// the `doc:"..."` and `enum:"a,b,c"` tags of the struct field.
+type ModelField struct {
+	Name        string // the JSON name
+	Type        string // the Go type, in markdown, linking to other models
+	Required    bool
+	Description string
+	Enum        []string
+}
+
+// Models lists the named struct types used by the Reads, Writes and
+// Returns samples of the routes, sorted by name, so that each is
+// described once however many routes use it.
+func (s *Service) Models() []Model {
+	defs := s.modelDefs()
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	models := make([]Model, 0, len(names))
+	for _, name := range names {
+		sc := defs[name]
+		m := Model{Name: name, Anchor: modelAnchor(name)}
+		props := make([]string, 0, len(sc.Properties))
+		for p := range sc.Properties {
+			props = append(props, p)
+		}
+		sort.Strings(props)
+		for _, p := range props {
+			ps := sc.Properties[p]
+			m.Fields = append(m.Fields, ModelField{
+				Name:        p,
+				Type:        modelType(ps),
+				Required:    inEnum(sc.Required, p),
+				Description: ps.Description,
+				Enum:        ps.Enum,
+			})
+		}
+		models = append(models, m)
+	}
+	return models
+}
+
+// modelDefs collects the schemas of the named types in the samples.
+func (s *Service) modelDefs() map[string]*Schema {
+	defs := make(map[string]*Schema)
+	for k, v := range s.schemas {
+		defs[k] = v
+	}
+	for _, r := range s.routes {
+		sampleSchema(r.readSchema, r.ReadSample, defs)
+		sampleSchema(r.writeSchema, r.WriteSample, defs)
+		for _, re := range r.ResponseErrors {
+			sampleSchema(re.schema, re.Model, defs)
+		}
+	}
+	return defs
+}
+
+// ReadModel links to the model of the route's Reads sample, or returns ""
+// if the sample isn't a named struct type (or a slice or map of one).
+func (s *Service) ReadModel(r Route) string {
+	return modelLink(sampleSchema(r.readSchema, r.ReadSample, make(map[string]*Schema)))
+}
+
+// WriteModel links to the model of the route's Writes sample, as for
+// ReadModel.
+func (s *Service) WriteModel(r Route) string {
+	return modelLink(sampleSchema(r.writeSchema, r.WriteSample, make(map[string]*Schema)))
+}
+
+// ResponseModel links to the model of a Returns sample, as for ReadModel.
+func (s *Service) ResponseModel(re ResponseError) string {
+	return modelLink(sampleSchema(re.schema, re.Model, make(map[string]*Schema)))
+}
+
+func modelLink(sc *Schema) string {
+	if _, ref := goTypeOf(sc); ref == "" {
+		return ""
+	}
+	return modelType(sc)
+}
+
+// modelAnchor is the id of a model's entry, like "model-api-widget".
+func modelAnchor(name string) string {
+	return "model-" + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, name)
+}
+
+// modelType formats the Go type of a schema in markdown, with a link if
+// it is (a slice, map or pointer of) a model.
+func modelType(sc *Schema) string {
+	prefix, ref := goTypeOf(sc)
+	if ref == "" {
+		return codeSpan(prefix)
+	}
+	link := "[" + codeSpan(ref) + "](#" + modelAnchor(ref) + ")"
+	if prefix == "" {
+		return link
+	}
+	return codeSpan(prefix) + link
+}
+
+// goTypeOf reconstructs the Go type of a schema. If the type is based on
+// a model, ref is the model's name and the rest of the type is in prefix.
+func goTypeOf(sc *Schema) (prefix, ref string) {
+	if sc == nil {
+		return "interface{}", ""
+	}
+	ptr := ""
+	if sc.Nullable && sc.Type != "array" && sc.Type != "object" {
+		ptr = "*"
+	}
+	if sc.Ref != "" {
+		return ptr, sc.RefName()
+	}
+	if sc.GoType != "" {
+		return ptr + sc.GoType, ""
+	}
+	switch sc.Type {
+	case "string":
+		switch sc.Format {
+		case "date-time":
+			return ptr + "time.Time", ""
+		case "byte":
+			return "[]byte", ""
+		}
+		return ptr + "string", ""
+	case "integer", "number":
+		if sc.Format != "" {
+			return ptr + sc.Format, ""
+		}
+		if sc.Type == "integer" {
+			return ptr + "int", ""
+		}
+		return ptr + "float64", ""
+	case "boolean":
+		return ptr + "bool", ""
+	case "array":
+		p, r := goTypeOf(sc.Items)
+		return "[]" + p, r
+	case "object":
+		if sc.AdditionalProperties != nil {
+			p, r := goTypeOf(sc.AdditionalProperties)
+			return "map[string]" + p, r
+		}
+	}
+	return "interface{}", ""
+}
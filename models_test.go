@@ -0,0 +1,100 @@
+package boneful
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	ID     string   `json:"id" doc:"the order number"`
+	Status string   `json:"status" enum:"open,shipped,closed" doc:"where the order is"`
+	Lines  []widget `json:"lines"`
+	Parent *order   `json:"parent,omitempty"`
+	Notes  []string `json:"notes,omitempty"`
+}
+
+type orderError struct {
+	Reason string `json:"reason"`
+}
+
+func modelService() *Service {
+	s := new(Service).Path("/")
+	s.Route(s.POST("/orders").To(SampleHandler).
+		Consumes("application/json").
+		Reads(order{}).
+		Produces("application/json").
+		Writes([]order{}).
+		Returns(409, "Conflict", orderError{}))
+	return s
+}
+
+func TestModels(t *testing.T) {
+	s := modelService()
+	models := s.Models()
+	names := []string{}
+	for _, m := range models {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"boneful.order", "boneful.orderError", "boneful.widget"}, names)
+
+	o := models[0]
+	assert.Equal(t, "model-boneful-order", o.Anchor)
+	assert.Equal(t, []ModelField{
+		{Name: "id", Type: "`string`", Required: true, Description: "the order number"},
+		{Name: "lines", Type: "`[]`[`boneful.widget`](#model-boneful-widget)", Required: true},
+		{Name: "notes", Type: "`[]string`"},
+		{Name: "parent", Type: "`*`[`boneful.order`](#model-boneful-order)"},
+		{Name: "status", Type: "`string`", Required: true, Description: "where the order is", Enum: []string{"open", "shipped", "closed"}},
+	}, o.Fields)
+
+	r := s.routes[0]
+	assert.Equal(t, "[`boneful.order`](#model-boneful-order)", s.ReadModel(r))
+	assert.Equal(t, "`[]`[`boneful.order`](#model-boneful-order)", s.WriteModel(r))
+	assert.Equal(t, "[`boneful.orderError`](#model-boneful-ordererror)", s.ResponseModel(r.ResponseErrors[409]))
+
+	buf := &bytes.Buffer{}
+	s.GenerateDocumentation(buf)
+	md := buf.String()
+	assert.Contains(t, md, "_**Reads:**_ [`boneful.order`](#model-boneful-order)\n")
+	assert.Contains(t, md, "409 | Conflict ([`boneful.orderError`](#model-boneful-ordererror))\n")
+	assert.Contains(t, md, "<a id=\"model-boneful-order\"></a>\n### `boneful.order`\n")
+	assert.Contains(t, md, "`status` | `string` | required | where the order is | `open, shipped, closed`\n")
+	assert.NotContains(t, md, "```json")
+
+	// the models are the schemas of the JSON documentation
+	w := httptest.NewRecorder()
+	s.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/jsondoc", nil))
+	assert.Contains(t, w.Body.String(), `"readSchema":{"$ref":"#/schemas/boneful.order"}`)
+	assert.Contains(t, w.Body.String(), `"id":{"type":"string","description":"the order number"}`)
+	assert.Contains(t, w.Body.String(), `"status":{"type":"string","description":"where the order is","enum":["open","shipped","closed"]}`)
+
+	// and survive a trip through it
+	doc := s.JSONDoc()
+	assert.Equal(t, "the order number", doc.Schemas["boneful.order"].Properties["id"].Description)
+	ns, err := NewServiceFromJSONDoc(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, models, ns.Models())
+	assert.Equal(t, s.ReadModel(r), ns.ReadModel(ns.routes[0]))
+}
+
+func TestModelsNone(t *testing.T) {
+	s := new(Service).Path("/")
+	s.Route(s.GET("/text").To(SampleHandler).Produces("text/plain").Writes("hello"))
+	assert.Empty(t, s.Models())
+	assert.Equal(t, "", s.WriteModel(s.routes[0]))
+	buf := &bytes.Buffer{}
+	s.GenerateDocumentation(buf)
+	assert.NotContains(t, buf.String(), "## Models")
+	assert.Contains(t, buf.String(), "```text\n        hello\n```")
+}
+
+func TestValidateEnum(t *testing.T) {
+	defs := map[string]*Schema{}
+	sc := SchemaOf(order{}, defs)
+	assert.Empty(t, sc.Validate([]byte(`{"id":"1","status":"open","lines":[]}`), defs))
+	assert.Equal(t, []string{`$.status: "lost" is not one of open, shipped, closed`},
+		sc.Validate([]byte(`{"id":"1","status":"lost","lines":[]}`), defs))
+}
@@ -0,0 +1,188 @@
+package boneful
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DocRenderer renders a Service's documentation in one output format.
+// The /doc endpoint chooses among the registered renderers using the
+// request's format query parameter or, failing that, its Accept header.
+type DocRenderer interface {
+	// Format is the short name used with ?format=, like "json".
+	Format() string
+	// ContentType is the media type of the rendered output.
+	ContentType() string
+	// Render writes the documentation for s to w.
+	Render(w io.Writer, s *Service) error
+}
+
+var (
+	renderersMu  sync.RWMutex
+	renderersGen uint64 // changes with each registration, to clear caches
+	renderers    = []DocRenderer{
+		markdownRenderer{},
+		jsonRenderer{},
+		yamlRenderer{},
+		htmlRenderer{},
+	}
+)
+
+// RegisterDocRenderer adds a documentation format, replacing any existing
+// renderer with the same Format. The first registered renderer (markdown)
+// is used when a request expresses no preference. Documentation that has
+// already been rendered and cached is rendered again.
+func RegisterDocRenderer(r DocRenderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderersGen++
+	for i, each := range renderers {
+		if each.Format() == r.Format() {
+			renderers[i] = r
+			return
+		}
+	}
+	renderers = append(renderers, r)
+}
+
+// DocRenderers returns the registered documentation renderers.
+func DocRenderers() []DocRenderer {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	return append([]DocRenderer(nil), renderers...)
+}
+
+func renderersGeneration() uint64 {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	return renderersGen
+}
+
+// rendererFor returns the registered renderer for format, or def if
+// there isn't one.
+func rendererFor(format string, def DocRenderer) DocRenderer {
+	for _, r := range DocRenderers() {
+		if r.Format() == format {
+			return r
+		}
+	}
+	return def
+}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) Format() string      { return "markdown" }
+func (markdownRenderer) ContentType() string { return "text/markdown; charset=utf-8" }
+func (markdownRenderer) Render(w io.Writer, s *Service) error {
+	return s.docTmpl().Execute(w, s)
+}
+
+type jsonRenderer struct{}
+
+func (jsonRenderer) Format() string      { return "json" }
+func (jsonRenderer) ContentType() string { return "application/json" }
+func (jsonRenderer) Render(w io.Writer, s *Service) error {
+	return json.NewEncoder(w).Encode(s.JSONDoc())
+}
+
+type yamlRenderer struct{}
+
+func (yamlRenderer) Format() string      { return "yaml" }
+func (yamlRenderer) ContentType() string { return "application/yaml" }
+func (yamlRenderer) Render(w io.Writer, s *Service) error {
+	buf := &bytes.Buffer{}
+	if err := (jsonRenderer{}).Render(buf, s); err != nil {
+		return err
+	}
+	return jsonToYAML(w, buf.Bytes())
+}
+
+type htmlRenderer struct{}
+
+func (htmlRenderer) Format() string      { return "html" }
+func (htmlRenderer) ContentType() string { return "text/html; charset=utf-8" }
+func (htmlRenderer) Render(w io.Writer, s *Service) error {
+	return htmlDocTemplate.Execute(w, s.htmlData())
+}
+
+// negotiateRenderer picks the renderer for a request, or nil if none of
+// them is acceptable.
+func negotiateRenderer(req *http.Request, available []DocRenderer) DocRenderer {
+	if len(available) == 0 {
+		return nil
+	}
+	if format := req.URL.Query().Get("format"); format != "" {
+		for _, r := range available {
+			if strings.EqualFold(r.Format(), format) {
+				return r
+			}
+		}
+		return nil
+	}
+
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return available[0]
+	}
+	type choice struct {
+		mediaType string
+		q         float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(qs, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{mt, q})
+		}
+	}
+	// the stable sort keeps the client's order for equal q-values
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+
+	for _, c := range choices {
+		for _, r := range available {
+			mt, _, _ := mime.ParseMediaType(r.ContentType())
+			switch {
+			case c.mediaType == "*/*", c.mediaType == mt:
+				return r
+			case strings.HasSuffix(c.mediaType, "/*") &&
+				strings.HasPrefix(mt, strings.TrimSuffix(c.mediaType, "*")):
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// GetDoc is a handler that returns the documentation in whichever of the
+// registered formats the client asks for, with ?format= taking precedence
+// over the Accept header.
+func (s *Service) GetDoc(rw http.ResponseWriter, req *http.Request) {
+	available := DocRenderers()
+	r := negotiateRenderer(req, available)
+	if r == nil {
+		formats := make([]string, 0, len(available))
+		for _, each := range available {
+			formats = append(formats, each.Format()+" ("+each.ContentType()+")")
+		}
+		http.Error(rw, "acceptable formats are: "+strings.Join(formats, ", "), http.StatusNotAcceptable)
+		return
+	}
+	rw.Header().Add("Vary", "Accept")
+	s.serveDoc(rw, req, r)
+}
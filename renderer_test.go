@@ -0,0 +1,139 @@
+package boneful
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type csvRenderer struct{}
+
+func (csvRenderer) Format() string      { return "csv" }
+func (csvRenderer) ContentType() string { return "text/csv" }
+func (csvRenderer) Render(w io.Writer, s *Service) error {
+	for _, r := range s.Routes() {
+		io.WriteString(w, r.Method+","+r.Path+"\n")
+	}
+	return nil
+}
+
+// restoreDocRenderers puts the renderer registry back the way it was
+// when the test ends, so that renderers registered by one test don't
+// show up in others.
+func restoreDocRenderers(t *testing.T) {
+	saved := DocRenderers()
+	t.Cleanup(func() {
+		renderersMu.Lock()
+		defer renderersMu.Unlock()
+		renderers = saved
+		renderersGen++
+	})
+}
+
+func getDoc(s *Service, url, accept string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	s.Mux().ServeHTTP(w, req)
+	return w
+}
+
+func TestDocNegotiation(t *testing.T) {
+	s := new(Service).Path("/")
+	s.Route(s.GET("/thing").To(SampleHandler).Doc("Get: the thing"))
+
+	w := getDoc(s, "/doc", "")
+	assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = getDoc(s, "/doc", "text/html;q=0.5, application/json")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"doc":"Get: the thing"`)
+
+	w = getDoc(s, "/doc?format=yaml", "application/json")
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "routes:\n  - curlExample: ")
+	assert.Contains(t, w.Body.String(), "    doc: \"Get: the thing\"\n")
+	assert.Contains(t, w.Body.String(), "    path: /thing\n")
+	assert.Contains(t, w.Body.String(), "version: \"1.0\"\n")
+
+	w = getDoc(s, "/doc", "image/png")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	t.Run("registered", func(t *testing.T) {
+		restoreDocRenderers(t)
+		RegisterDocRenderer(csvRenderer{})
+		w := getDoc(s, "/doc", "text/csv")
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Equal(t, "GET,/thing\n", w.Body.String())
+	})
+	assert.Nil(t, rendererFor("csv", nil))
+	w = getDoc(s, "/doc", "text/csv")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	w = getDoc(s, "/md", "")
+	assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+	w = getDoc(s, "/jsondoc", "")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func TestDocCaching(t *testing.T) {
+	s := new(Service).Path("/")
+	s.Route(s.GET("/thing").To(SampleHandler))
+	mux := s.Mux()
+
+	req, _ := http.NewRequest("GET", "/jsondoc", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, 0, w.Body.Len())
+
+	req, _ = http.NewRequest("GET", "/jsondoc", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	gzipETag := w.Header().Get("ETag")
+	assert.Equal(t, strings.TrimSuffix(etag, `"`)+`-gzip"`, gzipETag)
+	zr, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(zr)
+	assert.Contains(t, string(body), `"path":"/thing"`)
+
+	// each representation only matches its own ETag
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	req.Header.Set("If-None-Match", gzipETag)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	s.Route(s.GET("/other").To(SampleHandler).Operation("Other"))
+	req, _ = http.NewRequest("GET", "/jsondoc", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"path":"/other"`)
+
+	// registering a renderer clears the cache
+	r := rendererFor("json", nil)
+	before, _ := s.cache.get(s, r, "json")
+	restoreDocRenderers(t)
+	RegisterDocRenderer(r)
+	after, _ := s.cache.get(s, r, "json")
+	assert.False(t, before == after)
+}
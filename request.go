@@ -0,0 +1,173 @@
+package boneful
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+)
+
+// requestBuilder builds a request for a route from its documentation, as
+// CurlExample, GeneratePostman and GenerateHTTPFile write it out and as
+// ContractTest and FuzzRoutes send it. Each of them decides what goes in
+// the path variables; FuzzRoutes also picks the parameter values and
+// mangles the body.
+type requestBuilder struct {
+	pathVar func(d ParameterData) string   // the path segment for a variable
+	values  func(d ParameterData) []string // the values to send; nil for the exampleValue
+	body    func(sample string) string     // replaces the body; nil to send the ReadSample
+	indent  bool                           // indents JSON bodies, for people to read
+}
+
+// exampleRequest is a request built by a requestBuilder.
+type exampleRequest struct {
+	method      string
+	path        string         // with the variables filled in, without the query
+	vars        []requestValue // the path variables, documented or not
+	query       []requestValue
+	header      []requestValue
+	form        []requestValue
+	accept      string
+	contentType string
+	body        string // the ReadSample; form values are in form
+}
+
+// requestValue is a value of a parameter of an exampleRequest.
+type requestValue struct {
+	ParameterData
+	value   string
+	omitted bool // an optional parameter without a value, which isn't sent
+}
+
+func (rb requestBuilder) build(r Route) exampleRequest {
+	req := exampleRequest{method: r.Method, accept: firstOr(r.Produces, "")}
+	documented := make(map[string]bool)
+	for _, p := range r.ParameterDocs {
+		if d := p.Data(); d.Kind == PathParameterKind {
+			documented[d.Name] = true
+			req.vars = append(req.vars, requestValue{ParameterData: d, value: exampleValue(d)})
+		}
+	}
+	for _, m := range pathVariable.FindAllStringSubmatch(r.Path, -1) {
+		if !documented[m[1]] {
+			documented[m[1]] = true
+			d := ParameterData{Name: m[1], Kind: PathParameterKind, DataType: "string", Required: true}
+			req.vars = append(req.vars, requestValue{ParameterData: d})
+		}
+	}
+	req.path = exportPath(r.Path, func(name string) string {
+		for _, v := range req.vars {
+			if v.Name == name {
+				return rb.pathVar(v.ParameterData)
+			}
+		}
+		return name
+	})
+
+	for _, p := range r.ParameterDocs {
+		d := p.Data()
+		if d.Kind == PathParameterKind || d.Kind == BodyParameterKind {
+			continue
+		}
+		var values []requestValue
+		if rb.values != nil {
+			for _, v := range rb.values(d) {
+				values = append(values, requestValue{ParameterData: d, value: v})
+			}
+		} else {
+			v := exampleValue(d)
+			values = []requestValue{{ParameterData: d, value: v, omitted: v == "" && !d.Required}}
+		}
+		switch d.Kind {
+		case QueryParameterKind:
+			req.query = append(req.query, values...)
+		case HeaderParameterKind:
+			req.header = append(req.header, values...)
+		case FormParameterKind:
+			req.form = append(req.form, values...)
+		}
+	}
+
+	req.body = requestBody(r, rb.indent)
+	if rb.body != nil {
+		req.body = rb.body(req.body)
+	}
+	if req.body != "" {
+		req.contentType = firstOr(r.Consumes, "application/json")
+	} else if req.formBody() != "" {
+		req.contentType = "application/x-www-form-urlencoded"
+	}
+	return req
+}
+
+// target is the path and query of the request.
+func (req exampleRequest) target() string {
+	if q := encodeValues(req.query); q != "" {
+		return req.path + "?" + q
+	}
+	return req.path
+}
+
+// formBody is the form values, encoded as a request body.
+func (req exampleRequest) formBody() string {
+	return encodeValues(req.form)
+}
+
+// payload is what the request sends as its body.
+func (req exampleRequest) payload() string {
+	if req.body != "" {
+		return req.body
+	}
+	return req.formBody()
+}
+
+// httpRequest is the request, ready to send to a handler.
+func (req exampleRequest) httpRequest() *http.Request {
+	hr := httptest.NewRequest(req.method, req.target(), strings.NewReader(req.payload()))
+	for _, h := range req.header {
+		if !h.omitted {
+			hr.Header.Add(h.Name, h.value)
+		}
+	}
+	if req.contentType != "" {
+		hr.Header.Set("Content-Type", req.contentType)
+	}
+	if req.accept != "" {
+		hr.Header.Set("Accept", req.accept)
+	}
+	return hr
+}
+
+// encodeValues joins the values that are sent, in order, as for a query.
+func encodeValues(values []requestValue) string {
+	var pairs []string
+	for _, v := range values {
+		if !v.omitted {
+			pairs = append(pairs, url.QueryEscape(v.Name)+"="+url.QueryEscape(v.value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// requestBody is a route's ReadSample as a request body: as it is if it
+// is text, and otherwise as JSON, on one line unless indent is set.
+func requestBody(r Route, indent bool) string {
+	if r.ReadSample == nil {
+		return ""
+	}
+	if s, ok := r.ReadSample.(string); ok && isTextType(r.Consumes) {
+		return s
+	}
+	var b []byte
+	var err error
+	if indent {
+		b, err = json.MarshalIndent(exampleOf(r.ReadSample), "", "  ")
+	} else {
+		b, err = json.Marshal(exampleOf(r.ReadSample))
+	}
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,49 @@
+package boneful
+
+import (
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	s := new(Service).Path("/api")
+	s.Route(s.POST("/things/:id/#part^[a-z]+$").To(SampleHandler).
+		Param(PathParameter("id", "Thing id").DefaultValue("t 1")).
+		Param(QueryParameter("q", "Query").DefaultValue("a&b")).
+		Param(QueryParameter("page", "Page")).
+		Param(HeaderParameter("X-Trace", "Trace")).
+		Param(FormParameter("name", "Name").Required(true)).
+		Produces("application/json"))
+	r := s.Routes()[0]
+
+	req := requestBuilder{pathVar: func(d ParameterData) string { return d.Name + "=" + url.PathEscape(exampleValue(d)) }}.build(r)
+	assert.Equal(t, "/api/things/id=t%201/part=?q=a%26b", req.target())
+	assert.Equal(t, []string{"id", "part"}, []string{req.vars[0].Name, req.vars[1].Name})
+	assert.True(t, req.vars[1].Required)
+	assert.True(t, req.query[1].omitted)
+	assert.True(t, req.header[0].omitted)
+	assert.False(t, req.form[0].omitted)
+	assert.Equal(t, "", req.body)
+	assert.Equal(t, "name=", req.payload())
+	assert.Equal(t, "application/x-www-form-urlencoded", req.contentType)
+
+	hr := req.httpRequest()
+	assert.Equal(t, "application/json", hr.Header.Get("Accept"))
+	assert.Empty(t, hr.Header.Values("X-Trace"))
+	body, _ := io.ReadAll(hr.Body)
+	assert.Equal(t, "name=", string(body))
+
+	// the values and body can be chosen instead
+	req = requestBuilder{
+		pathVar: func(d ParameterData) string { return "x" },
+		values:  func(d ParameterData) []string { return []string{"1", "2"} },
+		body:    func(sample string) string { return "{}" },
+	}.build(r)
+	assert.Equal(t, "/api/things/x/x?q=1&q=2&page=1&page=2", req.target())
+	assert.Equal(t, "{}", req.payload())
+	assert.Equal(t, "application/json", req.contentType)
+	assert.Equal(t, []string{"1", "2"}, req.httpRequest().Header.Values("X-Trace"))
+}
@@ -0,0 +1,120 @@
+package boneful
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ValidationMode says what Mux does about responses that don't match the
+// documentation; see Service.ValidateResponses.
+type ValidationMode int
+
+const (
+	// ValidationOff serves responses as they are; it is the default
+	ValidationOff ValidationMode = iota
+
+	// ValidationLog logs each violation and serves the response anyway
+	ValidationLog
+
+	// ValidationFail logs each violation and replaces the response with
+	// a 500 that describes it
+	ValidationFail
+)
+
+// ValidateResponses makes the handlers in Mux check their responses
+// against the documentation, as ContractTest does: the status must be
+// 2xx or documented with Returns, a successful response's Content-Type
+// must be one the route Produces, and a JSON body must match the schema
+// of the Writes sample (or of the model documented for its status).
+// Responses are buffered to be checked, so this is meant for development
+// and testing. Violations are counted and served as JSON at
+// <root>/debug/validation.
+func (s *Service) ValidateResponses(mode ValidationMode) *Service {
+	s.validation = mode
+	return s
+}
+
+// Violation counts the responses of a route with the same problem.
+type Violation struct {
+	Route   string `json:"route"`
+	Problem string `json:"problem"`
+	Count   int    `json:"count"`
+}
+
+// violationCounter counts violations for the debug endpoint.
+type violationCounter struct {
+	mu     sync.Mutex
+	counts map[Violation]int // keyed with a zero Count
+}
+
+func (vc *violationCounter) reset() {
+	vc.mu.Lock()
+	vc.counts = nil
+	vc.mu.Unlock()
+}
+
+func (vc *violationCounter) add(route, problem string) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if vc.counts == nil {
+		vc.counts = make(map[Violation]int)
+	}
+	vc.counts[Violation{Route: route, Problem: problem}]++
+}
+
+// Violations returns the violations counted since Mux was last called,
+// sorted by route and problem.
+func (s *Service) Violations() []Violation {
+	vc := &s.violations
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	list := make([]Violation, 0, len(vc.counts))
+	for v, n := range vc.counts {
+		v.Count = n
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Route != list[j].Route {
+			return list[i].Route < list[j].Route
+		}
+		return list[i].Problem < list[j].Problem
+	})
+	return list
+}
+
+// GetViolations is a handler that serves Violations as JSON.
+func (s *Service) GetViolations(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(rw)
+	enc.SetIndent("", "  ")
+	enc.Encode(s.Violations())
+}
+
+// validating wraps the handler of a route to check its responses.
+func (s *Service) validating(r Route, h http.HandlerFunc, defs map[string]*Schema) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		problems := contractProblems(r, rec, defs)
+		for _, p := range problems {
+			s.violations.add(r.String(), p)
+			log.Printf("[boneful] response to %s %s violates the documentation of %s: %s", req.Method, req.URL, r, p)
+		}
+		if len(problems) > 0 && s.validation == ValidationFail {
+			http.Error(rw, fmt.Sprintf("[boneful] the response violates the documentation of %s:\n- %s",
+				r, strings.Join(problems, "\n- ")), http.StatusInternalServerError)
+			return
+		}
+		for k, v := range rec.Header() {
+			rw.Header()[k] = v
+		}
+		rw.WriteHeader(rec.Code)
+		rw.Write(rec.Body.Bytes())
+	}
+}
@@ -0,0 +1,62 @@
+package boneful
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateResponses(t *testing.T) {
+	s := new(Service).Path("/api")
+	s.Route(s.GET("/widget").To(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("how") {
+		case "teapot":
+			rw.WriteHeader(http.StatusTeapot)
+		case "text":
+			rw.Header().Set("Content-Type", "text/plain")
+			rw.Write([]byte("w1"))
+		default:
+			rw.Header().Set("Content-Type", "application/json")
+			rw.Write([]byte(`{"id":"w1","size":3}`))
+		}
+	}).
+		Operation("GetWidget").
+		Produces("application/json").
+		Writes(widget{}))
+
+	get := func(mux http.Handler, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w
+	}
+
+	mux := s.ValidateResponses(ValidationLog).Mux()
+	assert.Equal(t, 200, get(mux, "/api/widget").Code)
+	w := get(mux, "/api/widget?how=teapot")
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	get(mux, "/api/widget?how=teapot")
+	get(mux, "/api/widget?how=text")
+	assert.Equal(t, []Violation{
+		{Route: "GET /api/widget", Problem: `Content-Type is "text/plain", but the route Produces application/json`, Count: 1},
+		{Route: "GET /api/widget", Problem: "status 418 is not documented", Count: 2},
+	}, s.Violations())
+
+	w = get(mux, "/api/debug/validation")
+	var served []Violation
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &served))
+	assert.Equal(t, s.Violations(), served)
+
+	mux = s.ValidateResponses(ValidationFail).Mux()
+	assert.Empty(t, s.Violations())
+	assert.Equal(t, `{"id":"w1","size":3}`, get(mux, "/api/widget").Body.String())
+	w = get(mux, "/api/widget?how=teapot")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "violates the documentation of GET /api/widget:\n- status 418 is not documented")
+
+	// off by default
+	s.ValidateResponses(ValidationOff)
+	assert.Equal(t, 404, get(s.Mux(), "/api/debug/validation").Code)
+}
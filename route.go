@@ -2,7 +2,9 @@ package boneful
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/go-zoo/bone"
 )
@@ -13,6 +15,8 @@ type Route struct {
 	Method  string           `json:"method"`
 	Path    string           `json:"path"` // webservice root path + described path
 	Handler http.HandlerFunc `json:"-"`
+	Source  *SourceLocation  `json:"source,omitempty"`  // where Handler is defined
+	Planned bool             `json:"planned,omitempty"` // documented, but there's no Handler yet
 	muxfunc func(string, http.HandlerFunc) *bone.Route
 
 	// documentation
@@ -25,6 +29,13 @@ type Route struct {
 	ResponseErrors map[int]ResponseError `json:"-"`
 	ReadSample     interface{}           `json:"-"` // models an example request payload
 	WriteSample    interface{}           `json:"-"` // models an example response payload
+	Examples       []Example             `json:"-"` // named request examples, then response examples by status
+
+	// documented schemas, for routes read from a JSONDoc
+	readSchema  *Schema
+	writeSchema *Schema
+
+	nolint map[LintRule]bool // lint rules suppressed for this route
 }
 
 func (r *Route) postBuild() {
@@ -35,9 +46,36 @@ func (r Route) String() string {
 }
 
 // CodeFormat generates the marker for file contents for a code
-// block in Markdown
+// block in Markdown. It describes the request payload; it is kept for
+// templates written before ReadFormat and WriteFormat existed.
 func (r Route) CodeFormat() string {
-	for _, c := range r.Consumes {
+	return r.ReadFormat()
+}
+
+// ReadFormat is the code block language for the Reads example,
+// based on what the route Consumes.
+func (r Route) ReadFormat() string {
+	return codeFormat(r.Consumes)
+}
+
+// WriteFormat is the code block language for the Writes example,
+// based on what the route Produces.
+func (r Route) WriteFormat() string {
+	return codeFormat(r.Produces)
+}
+
+// Reads returns formatted example content for a Reads value
+func (r Route) Reads() string {
+	return formatSample(r.Consumes, r.ReadSample)
+}
+
+// Writes returns formatted example content for a Writes value
+func (r Route) Writes() string {
+	return formatSample(r.Produces, r.WriteSample)
+}
+
+func codeFormat(mimeTypes []string) string {
+	for _, c := range mimeTypes {
 		switch c {
 		case "text/plain":
 			return "text"
@@ -52,14 +90,20 @@ func (r Route) CodeFormat() string {
 	return ""
 }
 
-// Reads returns formatted example content for a Reads value
-func (r Route) Reads() string {
-	for _, c := range r.Consumes {
+// formatSample renders a sample payload for the first of the mimeTypes
+// that we know how to show.
+func formatSample(mimeTypes []string, sample interface{}) string {
+	if sample == nil {
+		return ""
+	}
+	for _, c := range mimeTypes {
 		switch c {
 		case "text/plain", "text/markdown", "text/html":
-			return r.ReadSample.(string)
+			if s, ok := sample.(string); ok {
+				return s
+			}
 		case "application/json":
-			b, err := json.MarshalIndent(r.ReadSample, "        ", "  ")
+			b, err := json.MarshalIndent(exampleOf(sample), "        ", "  ")
 			if err != nil {
 				continue
 			}
@@ -69,22 +113,67 @@ func (r Route) Reads() string {
 	return ""
 }
 
-// Writes returns formatted example content for a Writes value
-func (r Route) Writes() string {
-	for _, p := range r.Produces {
-		switch p {
-		case "text/plain":
-			if r.WriteSample != nil {
-				return r.WriteSample.(string)
-			}
-			return ""
-		case "application/json":
-			b, err := json.MarshalIndent(r.WriteSample, "        ", "  ")
-			if err != nil {
-				continue
-			}
-			return string(b)
+// Example is a named sample payload: of the request if Status is 0, or
+// else of the response with that status.
+type Example struct {
+	Status      int
+	Name        string
+	Description string
+	Value       interface{}
+}
+
+// Heading is the title of the example in the markdown documentation.
+func (e Example) Heading() string {
+	if e.Status == 0 {
+		return "Request example: " + e.Name
+	}
+	return fmt.Sprintf("Response example (%d): %s", e.Status, e.Name)
+}
+
+// ReadExamples returns the route's named request examples.
+func (r Route) ReadExamples() []Example {
+	var list []Example
+	for _, e := range r.Examples {
+		if e.Status == 0 {
+			list = append(list, e)
 		}
 	}
-	return ""
+	return list
+}
+
+// WriteExamples returns the route's named response examples, in order of
+// status.
+func (r Route) WriteExamples() []Example {
+	var list []Example
+	for _, e := range r.Examples {
+		if e.Status != 0 {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// ExampleFormat is the code block language for an example.
+func (r Route) ExampleFormat(e Example) string {
+	if e.Status == 0 {
+		return r.ReadFormat()
+	}
+	return r.WriteFormat()
+}
+
+// FormatExample returns formatted content for an example, as Reads and
+// Writes do for the samples.
+func (r Route) FormatExample(e Example) string {
+	if e.Status == 0 {
+		return formatSample(r.Consumes, e.Value)
+	}
+	return formatSample(r.Produces, e.Value)
+}
+
+// sortedExamples puts request examples first and response examples in
+// order of status, keeping the order they were given in otherwise.
+func sortedExamples(list []Example) []Example {
+	sorted := append([]Example(nil), list...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Status < sorted[j].Status })
+	return sorted
 }
@@ -2,9 +2,11 @@ package boneful
 
 // RouteBuilder is a helper to construct Routes.
 import (
+	"fmt"
 	"net/http"
 	"reflect"
 	"regexp"
+	"runtime"
 	"strings"
 )
 
@@ -23,8 +25,11 @@ type RouteBuilder struct {
 	operation   string
 	readSample  interface{}
 	writeSample interface{}
+	examples    []Example
 	parameters  []*Parameter
 	errorMap    map[int]ResponseError
+	nolint      map[LintRule]bool
+	planned     bool
 }
 
 // ResponseError is an error type returned from this API
@@ -32,6 +37,7 @@ type ResponseError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Model   interface{} `json:"model"`
+	schema  *Schema     // documented schema, for responses read from a JSONDoc
 }
 
 // NewRouteBuilder constructs an empty RouteBuilder.
@@ -54,6 +60,13 @@ func (b *RouteBuilder) To(function http.HandlerFunc) *RouteBuilder {
 	return b
 }
 
+// Planned marks a route that is documented but not implemented yet, so
+// that it needs no handler. See Service.DesignFirst.
+func (b *RouteBuilder) Planned() *RouteBuilder {
+	b.planned = true
+	return b
+}
+
 // Method specifies what HTTP method to match. Required.
 func (b *RouteBuilder) Method(method string) *RouteBuilder {
 	b.httpMethod = method
@@ -95,10 +108,18 @@ func (b *RouteBuilder) Notes(notes string) *RouteBuilder {
 }
 
 // Reads tells what resource type will be read from the request payload. Optional.
-// A parameter of type "body" is added ,required is set to true and the dataType is set to the qualified name of the sample's type.
+// A parameter of type "body" is added (or the existing one is updated), required is set to true and the dataType is set to the qualified name of the sample's type.
 func (b *RouteBuilder) Reads(sample interface{}) *RouteBuilder {
 	b.readSample = sample
 	typeAsName := reflect.TypeOf(sample).String()
+	for _, p := range b.parameters {
+		if p.Data().Kind == BodyParameterKind {
+			// there is only one body; this updates its type
+			p.Required(true)
+			p.DataType(typeAsName)
+			return b
+		}
+	}
 	bodyParameter := &Parameter{&ParameterData{Name: "body"}}
 	bodyParameter.beBody()
 	bodyParameter.Required(true)
@@ -124,6 +145,40 @@ func (b *RouteBuilder) Writes(sample interface{}) *RouteBuilder {
 	return b
 }
 
+// ReadsExample adds a named example of the request payload, for routes
+// that accept several shapes of request. If Reads hasn't been called, the
+// first example also serves as the Reads sample. The examples are
+// documented in the order they are added; ReadsExample panics if the
+// name is already used by another request example.
+func (b *RouteBuilder) ReadsExample(name, description string, value interface{}) *RouteBuilder {
+	if b.readSample == nil {
+		b.Reads(value)
+	}
+	b.addExample(Example{Name: name, Description: description, Value: value})
+	return b
+}
+
+// WritesExample adds a named example of the response payload for a status
+// code. If Writes hasn't been called, the first 2xx example also serves
+// as the Writes sample. As for ReadsExample, names must be unique (for
+// each status code).
+func (b *RouteBuilder) WritesExample(status int, name, description string, value interface{}) *RouteBuilder {
+	if b.writeSample == nil && isSuccess(status) {
+		b.writeSample = value
+	}
+	b.addExample(Example{Status: status, Name: name, Description: description, Value: value})
+	return b
+}
+
+func (b *RouteBuilder) addExample(e Example) {
+	for _, x := range b.examples {
+		if x.Status == e.Status && x.Name == e.Name {
+			panic(fmt.Sprintf("[boneful] %q is already defined for route %s %s", e.Heading(), b.httpMethod, concatPath(b.rootPath, b.currentPath)))
+		}
+	}
+	b.examples = append(b.examples, e)
+}
+
 // Param allows you to document the parameters of the Route. It adds a new Parameter (does not check for duplicates).
 func (b *RouteBuilder) Param(parameter *Parameter) *RouteBuilder {
 	b.parameters = append(b.parameters, parameter)
@@ -149,9 +204,24 @@ func (b *RouteBuilder) Returns(code int, message string, model interface{}) *Rou
 	return b
 }
 
+// NoLint suppresses the given lint rules for this route, or all of them
+// if none are given. See Service.Lint.
+func (b *RouteBuilder) NoLint(rules ...LintRule) *RouteBuilder {
+	if b.nolint == nil {
+		b.nolint = make(map[LintRule]bool)
+	}
+	if len(rules) == 0 {
+		rules = []LintRule{lintAll}
+	}
+	for _, r := range rules {
+		b.nolint[r] = true
+	}
+	return b
+}
+
 // Build creates a new Route using the specification details collected by the RouteBuilder
 func (b *RouteBuilder) Build() Route {
-	if b.handler == nil {
+	if b.handler == nil && !b.planned {
 		panic("[boneful] No function specified for route:" + b.currentPath)
 	}
 	route := Route{
@@ -160,18 +230,86 @@ func (b *RouteBuilder) Build() Route {
 		Produces:       b.produces,
 		Consumes:       b.consumes,
 		Handler:        b.handler,
+		Source:         handlerSource(b.handler),
+		Planned:        b.planned,
 		Doc:            b.doc,
 		Notes:          b.notes,
-		Operation:      b.operation,
+		Operation:      b.operationName(),
 		ParameterDocs:  b.parameters,
 		ResponseErrors: b.errorMap,
 		ReadSample:     b.readSample,
 		WriteSample:    b.writeSample,
+		Examples:       sortedExamples(b.examples),
+		nolint:         b.nolint,
 	}
 	route.postBuild()
 	return route
 }
 
+// operationName returns the explicitly set operation or, failing that,
+// the name derived from the handler function. A planned route without
+// a handler is named for its method and path.
+func (b *RouteBuilder) operationName() string {
+	if b.operation != "" {
+		return b.operation
+	}
+	if b.handler == nil && b.planned {
+		return b.httpMethod + " " + concatPath(b.rootPath, b.currentPath)
+	}
+	name, _ := handlerName(b.handler)
+	return name
+}
+
+var closureSegment = regexp.MustCompile(`^(func)?[0-9]+$`)
+
+// handlerName derives an operation name from a handler function using
+// the runtime symbol table. The package qualification is stripped, as
+// is the receiver of a method value, so that "pkg.(*Svc).GetThing-fm"
+// becomes "GetThing". Closures can't be named that way; they are given
+// a name based on their enclosing function (such as "setup_func1") and
+// anonymous is returned as true so that callers can explain the result.
+func handlerName(h http.HandlerFunc) (name string, anonymous bool) {
+	if h == nil {
+		return "", false
+	}
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return "", false
+	}
+	name = fn.Name()
+	// generic instantiations look like Handler[...]
+	if ix := strings.Index(name, "["); ix >= 0 {
+		if end := strings.LastIndex(name, "]"); end > ix {
+			name = name[:ix] + name[end+1:]
+		}
+	}
+	// strip the package path and then the package name
+	if ix := strings.LastIndex(name, "/"); ix >= 0 {
+		name = name[ix+1:]
+	}
+	if ix := strings.Index(name, "."); ix >= 0 {
+		name = name[ix+1:]
+	}
+	// method values are wrapped by the compiler with a -fm suffix
+	name = strings.TrimSuffix(name, "-fm")
+	// drop the receiver of a method, as in (*Service).GetDocMD
+	if ix := strings.LastIndex(name, ")."); ix >= 0 {
+		name = name[ix+2:]
+	}
+
+	parts := strings.Split(name, ".")
+	for _, p := range parts[1:] {
+		if closureSegment.MatchString(p) {
+			anonymous = true
+			break
+		}
+	}
+	if anonymous {
+		return strings.Join(parts, "_"), true
+	}
+	return parts[len(parts)-1], false
+}
+
 func concatPath(path1, path2 string) string {
 	return strings.TrimRight(path1, "/") + "/" + strings.TrimLeft(path2, "/")
 }
@@ -0,0 +1,176 @@
+package boneful
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema style description of a payload type, derived
+// from the Go type of a sample by reflection. Named struct types are
+// described once, in the Schemas of the JSONDoc, and referred to by Ref.
+// The Description and Enum of a struct field come from its `doc:"..."`
+// and `enum:"a,b,c"` tags.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	GoType               string             `json:"goType,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// schemaRefPrefix begins the Ref of a Schema that lives in JSONDoc.Schemas.
+const schemaRefPrefix = "#/schemas/"
+
+// RefName returns the name of the schema this one refers to, if any.
+func (sc *Schema) RefName() string {
+	if sc == nil {
+		return ""
+	}
+	return strings.TrimPrefix(sc.Ref, schemaRefPrefix)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaOf describes the JSON encoding of sample's type. Named struct
+// types it encounters are added to defs (keyed by their qualified Go
+// name) and referred to by Ref, which also keeps recursive types finite.
+// A nil sample has no schema.
+func SchemaOf(sample interface{}, defs map[string]*Schema) *Schema {
+	if sample == nil {
+		return nil
+	}
+	return schemaForType(reflect.TypeOf(sample), defs)
+}
+
+func schemaForType(t reflect.Type, defs map[string]*Schema) *Schema {
+	if t.Kind() == reflect.Ptr {
+		sc := schemaForType(t.Elem(), defs)
+		if sc.Ref != "" {
+			return &Schema{Ref: sc.Ref, Nullable: true}
+		}
+		sc.Nullable = true
+		return sc
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return &Schema{GoType: t.String()}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string", GoType: t.String()}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: t.Kind().String()}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: t.Kind().String()}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaForType(t.Elem(), defs)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaForType(t.Elem(), defs)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, defs)
+		}
+		name := t.String()
+		if _, ok := defs[name]; !ok {
+			// reserve the name first, in case the type refers to itself
+			defs[name] = &Schema{}
+			*defs[name] = *structSchema(t, defs)
+		}
+		return &Schema{Ref: schemaRefPrefix + name}
+	default:
+		// interfaces, and things JSON can't encode anyway
+		return &Schema{}
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]*Schema) *Schema {
+	sc := &Schema{Type: "object", GoType: t.String(), Properties: make(map[string]*Schema)}
+	for _, f := range jsonFields(t) {
+		fs := schemaForType(f.Type, defs)
+		if f.asString {
+			fs = &Schema{Type: "string", Format: fs.Format}
+		}
+		fs.Description = f.Tag.Get("doc")
+		if enum := f.Tag.Get("enum"); enum != "" {
+			fs.Enum = strings.Split(enum, ",")
+		}
+		sc.Properties[f.Name] = fs
+		if !f.omitEmpty {
+			sc.Required = append(sc.Required, f.Name)
+		}
+	}
+	return sc
+}
+
+// jsonField is a struct field as encoding/json sees it.
+type jsonField struct {
+	reflect.StructField
+	Name      string
+	omitEmpty bool
+	asString  bool
+}
+
+// jsonFields lists the fields of a struct type that encoding/json would
+// encode, following embedded structs the way it does.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if ix := strings.Index(tag, ","); ix >= 0 {
+			name, opts = tag[:ix], tag[ix:]
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(ft)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{
+			StructField: f,
+			Name:        name,
+			omitEmpty:   strings.Contains(opts, ",omitempty"),
+			asString:    strings.Contains(opts, ",string"),
+		})
+	}
+	return fields
+}
@@ -0,0 +1,129 @@
+package boneful
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Server is a base URL at which the service can be reached, such as
+// "http://localhost:8080"; the paths of the routes are relative to it.
+// The URL can contain variables in braces, as in
+// "https://{region}.api.example.com", which are described by Variables.
+type Server struct {
+	Name        string                    `json:"name"`
+	URL         string                    `json:"url"`
+	Description string                    `json:"description,omitempty"`
+	Variables   map[string]ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable is a variable in the URL of a Server.
+type ServerVariable struct {
+	Default     string   `json:"default"`
+	Enum        []string `json:"enum,omitempty"` // the allowed values, if they are limited
+	Description string   `json:"description,omitempty"`
+}
+
+// Expand returns the URL with each variable replaced by its default.
+func (sv Server) Expand() string {
+	u := sv.URL
+	for name, v := range sv.Variables {
+		u = strings.Replace(u, "{"+name+"}", v.Default, -1)
+	}
+	return u
+}
+
+// VariableNames returns the names of the variables, sorted.
+func (sv Server) VariableNames() []string {
+	names := make([]string, 0, len(sv.Variables))
+	for name := range sv.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultServer is used in examples when no Servers are declared.
+var defaultServer = Server{Name: "local", URL: "http://localhost:8080"}
+
+// Servers declares where the service is deployed, such as local, staging
+// and production. They are listed in the documentation, and examples use
+// the first of them.
+func (s *Service) Servers(servers ...Server) *Service {
+	s.servers = servers
+	s.cache.invalidate()
+	return s
+}
+
+// ServerList returns the servers declared with Servers.
+func (s *Service) ServerList() []Server {
+	return s.servers
+}
+
+// CurrentServer makes documentation that is served over HTTP list the
+// server it was requested from first, under the given name, so that the
+// examples in it work against that server. The server is derived from
+// the request's Host and TLS state (or from proxy headers, with
+// TrustForwardedHeaders); a Host that isn't a plain host name and port
+// is ignored. An empty name turns this off.
+func (s *Service) CurrentServer(name string) *Service {
+	s.currentServer = name
+	s.cache.invalidate()
+	return s
+}
+
+// TrustForwardedHeaders makes CurrentServer use the X-Forwarded-Host and
+// X-Forwarded-Proto headers. Turn it on only behind a proxy that sets
+// them, since otherwise any client can choose what they say.
+func (s *Service) TrustForwardedHeaders(trust bool) *Service {
+	s.trustForwarded = trust
+	s.cache.invalidate()
+	return s
+}
+
+// validHost matches a host name or IP address, with an optional port.
+var validHost = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?|\[[0-9A-Fa-f:.]+\])(:[0-9]{1,5})?$`)
+
+// requestServer is the server that req was sent to, if it can be told.
+func requestServer(req *http.Request, name string, trustForwarded bool) (Server, bool) {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	host := req.Host
+	if trustForwarded {
+		if p := forwarded(req, "X-Forwarded-Proto"); p == "http" || p == "https" {
+			scheme = p
+		}
+		if h := forwarded(req, "X-Forwarded-Host"); h != "" {
+			host = h
+		}
+	}
+	if !validHost.MatchString(host) {
+		return Server{}, false
+	}
+	return Server{Name: name, URL: scheme + "://" + host, Description: "The server this documentation was served from"}, true
+}
+
+// forwarded returns the first value of a proxy header, which is the one
+// set by the proxy closest to the client.
+func forwarded(req *http.Request, header string) string {
+	return strings.TrimSpace(strings.SplitN(req.Header.Get(header), ",", 2)[0])
+}
+
+// withServers returns a copy of s, for rendering, with different servers.
+// Only the configuration is copied; the cache and counters are not.
+func (s *Service) withServers(servers []Server) *Service {
+	v := &Service{serviceConfig: s.serviceConfig}
+	v.servers = servers
+	return v
+}
+
+// exampleServer is the server that examples are written for.
+func (s *Service) exampleServer() Server {
+	if len(s.servers) > 0 {
+		return s.servers[0]
+	}
+	return defaultServer
+}
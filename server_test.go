@@ -0,0 +1,97 @@
+package boneful
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serverService() *Service {
+	s := new(Service).Path("/api").Doc("Servers").Servers(
+		Server{Name: "prod", URL: "https://{region}.api.example.com", Description: "Production",
+			Variables: map[string]ServerVariable{
+				"region": {Default: "us", Enum: []string{"us", "eu"}, Description: "Data residency"},
+			}},
+		Server{Name: "local", URL: "http://localhost:8080"},
+	)
+	s.Route(s.GET("/ping").To(SampleHandler).Doc("Ping"))
+	return s
+}
+
+func TestServers(t *testing.T) {
+	s := serverService()
+	assert.Equal(t, "https://us.api.example.com", s.ServerList()[0].Expand())
+	assert.Equal(t, "curl 'https://us.api.example.com/api/ping'", s.CurlExample(s.Routes()[0]))
+
+	buf := &bytes.Buffer{}
+	s.GenerateDocumentation(buf)
+	assert.Contains(t, buf.String(), "Servers\n\n_**Servers:**_\n\n"+
+		"Name | URL | Description\n---- | --- | -----------\n"+
+		"prod | `https://{region}.api.example.com` | Production\n"+
+		"local | `http://localhost:8080` | \n\n"+
+		"* `{region}` in prod: default `us`, one of `us, eu` -- Data residency\n")
+
+	doc := s.JSONDoc()
+	assert.Equal(t, s.ServerList(), doc.Service.Servers)
+	rs, err := NewServiceFromJSONDoc(doc)
+	assert.Nil(t, err)
+	assert.Equal(t, s.ServerList(), rs.ServerList())
+}
+
+func TestCurrentServer(t *testing.T) {
+	s := serverService().CurrentServer("current")
+	mux := s.Mux()
+	get := func(host, proto string) string {
+		req, _ := http.NewRequest("GET", "/api/md", nil)
+		req.Host = host
+		if proto != "" {
+			req.Header.Set("X-Forwarded-Proto", proto)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	md := get("one.example.com:8080", "")
+	assert.Contains(t, md, "current | `http://one.example.com:8080` |")
+	assert.Contains(t, md, "curl 'http://one.example.com:8080/api/ping'")
+
+	// proxy headers are ignored unless they are trusted
+	md = get("two.example.com", "https")
+	assert.Contains(t, md, "curl 'http://two.example.com/api/ping'")
+	s.TrustForwardedHeaders(true)
+	mux = s.Mux()
+	md = get("two.example.com", "https")
+	assert.Contains(t, md, "curl 'https://two.example.com/api/ping'")
+	assert.NotContains(t, md, "one.example.com")
+
+	// a Host that isn't one is left out
+	md = get("evil.example.com/<script>", "")
+	assert.NotContains(t, md, "evil")
+	assert.NotContains(t, md, "current |")
+
+	// the declared servers are unchanged
+	assert.Len(t, s.ServerList(), 2)
+}
+
+func TestDocCacheEviction(t *testing.T) {
+	s := serverService()
+	r := rendererFor("markdown", nil)
+	first, err := s.cache.get(s, r, "first")
+	assert.Nil(t, err)
+	for i := 1; i < maxCachedDocs; i++ {
+		s.cache.get(s, r, fmt.Sprint(i))
+	}
+	// using "first" again makes "1" the least recently used
+	d, _ := s.cache.get(s, r, "first")
+	assert.True(t, d == first)
+	s.cache.get(s, r, "new")
+	assert.Len(t, s.cache.docs, maxCachedDocs)
+	assert.Contains(t, s.cache.docs, "new")
+	assert.Contains(t, s.cache.docs, "first")
+	assert.NotContains(t, s.cache.docs, "1")
+}
@@ -2,10 +2,10 @@ package boneful
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"regexp"
-	"strings"
 	"text/template"
 
 	"github.com/go-zoo/bone"
@@ -13,53 +13,123 @@ import (
 
 // Service is the base type for what users of the API will manage
 type Service struct {
-	rootPath      string
-	routes        []Route
-	documentation string
+	serviceConfig
+
+	cache      docCache
+	violations violationCounter
+}
+
+// serviceConfig is everything that describes a Service, as opposed to the
+// state it keeps while serving, so that it can be copied as a whole.
+type serviceConfig struct {
+	rootPath       string
+	routes         []Route
+	documentation  string
+	sourceRoot     string
+	sourceURL      *template.Template
+	docTemplate    *template.Template
+	schemas        map[string]*Schema // documented schemas, for a Service read from a JSONDoc
+	lintSeverities map[LintRule]Severity
+	designFirst    bool
+	serveSamples   bool
+	servers        []Server
+	currentServer  string // name under which to list the requested server, if at all
+	trustForwarded bool   // whether the current server comes from X-Forwarded headers
+	validation     ValidationMode
 }
 
 // GenerateDocumentation is used to spit out markdown format of docs.
+// It uses the built-in template unless one was set with DocTemplate.
 func (s *Service) GenerateDocumentation(w io.Writer) {
-	funcMap := template.FuncMap{
-		// The name "lower" is what the function will be called in the template text.
-		"lower": strings.ToLower,
+	markdownRenderer{}.Render(w, s)
+}
+
+func (s *Service) docTmpl() *template.Template {
+	if s.docTemplate != nil {
+		return s.docTemplate
 	}
-	tmpl := template.Must(template.New("md").Funcs(funcMap).Parse(mdTemplate))
-	tmpl.Execute(w, s)
+	return template.Must(template.New("md").Funcs(TemplateFuncs()).Parse(mdTemplate))
 }
 
-// GenerateJSONDoc emits JSON-formatted documentation info
+// GenerateJSONDoc emits JSON-formatted documentation info; see JSONDoc.
 func (s *Service) GenerateJSONDoc(w io.Writer) {
-	// fmt.Println(len(s.routes))
-	// r := s.routes[0]
-	// fmt.Printf("%#v\n", r)
-	// j, err := json.Marshal(r)
-	// if err != nil {
-	// 	fmt.Println(err)
-	// 	return
-	// }
-	// fmt.Println(j)
-	json.NewEncoder(w).Encode(s.routes)
+	jsonRenderer{}.Render(w, s)
 }
 
 // Mux returns a multiplexer that can be used as a master handler to
 // route requests to the appropriate handler.
 func (s *Service) Mux() *bone.Mux {
+	return s.muxWith(nil)
+}
+
+// muxWith builds the multiplexer for Mux, with the handler of each route
+// wrapped by wrap, if it is given.
+func (s *Service) muxWith(wrap func(Route, http.HandlerFunc) http.HandlerFunc) *bone.Mux {
+	var defs map[string]*Schema
+	if s.validation != ValidationOff {
+		s.violations.reset()
+		// every schema the checks need, so that they only read defs
+		defs = s.JSONDoc().Schemas
+		if defs == nil {
+			defs = make(map[string]*Schema)
+		}
+	}
+	mux := s.mux(func(r Route) http.HandlerFunc {
+		h := r.Handler
+		if h == nil {
+			h = s.plannedHandler(r)
+		} else if s.validation != ValidationOff {
+			h = s.validating(r, h, defs)
+		}
+		if wrap != nil {
+			h = wrap(r, h)
+		}
+		return h
+	})
+	if s.validation != ValidationOff {
+		mux.GetFunc(concatPath(s.RootPath(), "/debug/validation"), s.GetViolations)
+	}
+	return mux
+}
+
+// DesignFirst lets routes be added before they are implemented: a route
+// without a handler is accepted and marked as Planned instead of causing
+// a panic. Planned routes respond with 501 Not Implemented or, if
+// serveSamples is true, as they would in MockMux.
+func (s *Service) DesignFirst(serveSamples bool) *Service {
+	s.designFirst = true
+	s.serveSamples = serveSamples
+	return s
+}
+
+func (s *Service) plannedHandler(r Route) http.HandlerFunc {
+	if s.serveSamples {
+		return mockHandler(r)
+	}
+	return func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, fmt.Sprintf("%s is planned but not implemented yet", r), http.StatusNotImplemented)
+	}
+}
+
+// mux builds a multiplexer for the routes, using handler to pick the
+// handler for each, and adds the documentation endpoints.
+func (s *Service) mux(handler func(Route) http.HandlerFunc) *bone.Mux {
 	mux := bone.New()
 	for _, r := range s.routes {
+		h := handler(r)
 		switch r.Method {
 		case "HEAD":
-			mux.HeadFunc(r.Path, r.Handler)
+			mux.HeadFunc(r.Path, h)
 		case "GET":
-			mux.GetFunc(r.Path, r.Handler)
+			mux.GetFunc(r.Path, h)
 		case "POST":
-			mux.PostFunc(r.Path, r.Handler)
+			mux.PostFunc(r.Path, h)
 		case "PUT":
-			mux.PutFunc(r.Path, r.Handler)
+			mux.PutFunc(r.Path, h)
 		case "PATCH":
-			mux.PatchFunc(r.Path, r.Handler)
+			mux.PatchFunc(r.Path, h)
 		case "DELETE":
-			mux.DeleteFunc(r.Path, r.Handler)
+			mux.DeleteFunc(r.Path, h)
 		}
 	}
 
@@ -76,6 +146,10 @@ func (s *Service) Mux() *bone.Mux {
 		return false
 	}
 
+	doc := concatPath(s.RootPath(), "/doc")
+	if !hasRoute(doc) {
+		mux.GetFunc(doc, s.GetDoc)
+	}
 	mdpath := concatPath(s.RootPath(), "/md")
 	if !hasRoute(mdpath) {
 		mux.GetFunc(mdpath, s.GetDocMD)
@@ -84,10 +158,16 @@ func (s *Service) Mux() *bone.Mux {
 	if !hasRoute(jsondoc) {
 		mux.GetFunc(jsondoc, s.GetJSONDoc)
 	}
+	docs := concatPath(s.RootPath(), "/docs")
+	if !hasRoute(docs) {
+		mux.GetFunc(docs, s.GetDocHTML)
+	}
 	health := concatPath(s.RootPath(), "/health")
 	if !hasRoute(health) {
 		mux.GetFunc(health, s.HealthCheck)
 	}
+	s.cache.precompute(s)
+
 	// for verb, routes := range mux.Routes {
 	// 	for _, r := range routes {
 	// 		fmt.Printf("%s %#v\n", verb, *r)
@@ -99,12 +179,12 @@ func (s *Service) Mux() *bone.Mux {
 
 // GetDocMD is a handler for markdown documentation.
 func (s *Service) GetDocMD(rw http.ResponseWriter, req *http.Request) {
-	s.GenerateDocumentation(rw)
+	s.serveDoc(rw, req, rendererFor("markdown", markdownRenderer{}))
 }
 
 // GetJSONDoc is a handler to return JSON documentation
 func (s *Service) GetJSONDoc(rw http.ResponseWriter, req *http.Request) {
-	s.GenerateJSONDoc(rw)
+	s.serveDoc(rw, req, rendererFor("json", jsonRenderer{}))
 }
 
 // HealthCheck is a rudimentary endpoint that simply returns "OK".
@@ -118,12 +198,32 @@ func (s *Service) HealthCheck(rw http.ResponseWriter, req *http.Request) {
 // All Routes will be relative to this path.
 func (s *Service) Path(root string) *Service {
 	s.rootPath = root
+	s.cache.invalidate()
 	return s
 }
 
 // Route creates a new Route using the RouteBuilder and add to the ordered list of Routes.
+// Operation names must be unique within a Service, since they are used to
+// identify (and link to) routes in the documentation; Route panics if
+// the new route's Operation is already in use. In DesignFirst mode, a
+// builder without a handler makes a Planned route.
 func (s *Service) Route(builder *RouteBuilder) *Service {
-	s.routes = append(s.routes, builder.Build())
+	if s.designFirst && builder.handler == nil {
+		builder.Planned()
+	}
+	route := builder.Build()
+	for _, r := range s.routes {
+		if r.Operation != route.Operation {
+			continue
+		}
+		msg := fmt.Sprintf("[boneful] Operation %q for route %s is already used by route %s", route.Operation, route, r)
+		if name, anon := handlerName(route.Handler); anon && name == route.Operation {
+			msg += "; the handler is an anonymous function, so use Operation(..) to give the route a name"
+		}
+		panic(msg)
+	}
+	s.routes = append(s.routes, route)
+	s.cache.invalidate()
 	return s
 }
 
@@ -144,6 +244,7 @@ func (s *Service) RootPath() string {
 func (s *Service) Doc(plainText string) *Service {
 	re := regexp.MustCompile("\n[ \t]+")
 	s.documentation = re.ReplaceAllString(plainText, "\n")
+	s.cache.invalidate()
 	return s
 }
 
@@ -152,9 +253,14 @@ func (s *Service) Documentation() string {
 	return s.documentation
 }
 
-// Routes returns the array of routes defined for this service.
+// Routes returns the array of routes defined for this service, with the
+// doc comments registered for their handlers filled in.
 func (s *Service) Routes() []Route {
-	return s.routes
+	routes := make([]Route, len(s.routes))
+	for i, r := range s.routes {
+		routes[i] = r.withHandlerDoc()
+	}
+	return routes
 }
 
 /*
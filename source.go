@@ -0,0 +1,223 @@
+package boneful
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// SourceLocation records where a route's handler function is defined.
+type SourceLocation struct {
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// HandlerDoc holds the Go doc comment and position of a handler function.
+// These are normally extracted from source by the bonedoc command, which
+// writes a file that registers them with RegisterHandlerDocs.
+type HandlerDoc struct {
+	Doc  string
+	File string
+	Line int
+}
+
+var (
+	handlerDocsMu  sync.RWMutex
+	handlerDocsGen uint64 // changes with each registration, to clear caches
+	handlerDocs    = make(map[string]HandlerDoc)
+)
+
+// RegisterHandlerDocs makes doc comments available to routes whose handlers
+// are the named functions. Keys are fully qualified function names as
+// reported by the runtime, such as "github.com/me/svc.(*API).GetThing".
+// When a route has no Doc or Notes, they are filled in from the matching
+// comment: the first paragraph becomes the Doc and the rest becomes the
+// Notes. This happens as the routes are documented, not as they are
+// built, so a service may be built before the docs are registered (say,
+// in a package-level var, before the init function bonedoc writes runs).
+func RegisterHandlerDocs(docs map[string]HandlerDoc) {
+	handlerDocsMu.Lock()
+	defer handlerDocsMu.Unlock()
+	handlerDocsGen++
+	for k, v := range docs {
+		handlerDocs[k] = v
+	}
+}
+
+func handlerDocsGeneration() uint64 {
+	handlerDocsMu.RLock()
+	defer handlerDocsMu.RUnlock()
+	return handlerDocsGen
+}
+
+func lookupHandlerDoc(function string) (HandlerDoc, bool) {
+	handlerDocsMu.RLock()
+	defer handlerDocsMu.RUnlock()
+	hd, ok := handlerDocs[function]
+	return hd, ok
+}
+
+// handlerSource finds the function behind a handler and where it lives.
+// Method values are compiled into autogenerated wrappers, so for those we
+// fall back on the position recorded by RegisterHandlerDocs, if any.
+func handlerSource(h http.HandlerFunc) *SourceLocation {
+	if h == nil {
+		return nil
+	}
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return nil
+	}
+	loc := &SourceLocation{Function: strings.TrimSuffix(fn.Name(), "-fm")}
+	file, line := fn.FileLine(fn.Entry())
+	if !strings.HasPrefix(file, "<") {
+		loc.File, loc.Line = file, line
+	} else if hd, ok := lookupHandlerDoc(loc.Function); ok {
+		loc.File, loc.Line = hd.File, hd.Line
+	}
+	return loc
+}
+
+// withHandlerDoc fills in what the route doesn't document itself from
+// the registered doc comment of its handler, if there is one.
+func (r Route) withHandlerDoc() Route {
+	if r.Source == nil {
+		return r
+	}
+	hd, ok := lookupHandlerDoc(r.Source.Function)
+	if !ok {
+		return r
+	}
+	if r.Source.File == "" {
+		loc := *r.Source
+		loc.File, loc.Line = hd.File, hd.Line
+		r.Source = &loc
+	}
+	first, rest := splitDoc(hd.Doc)
+	if r.Doc == "" {
+		r.Doc = first
+	}
+	if r.Notes == "" {
+		r.Notes = rest
+	}
+	return r
+}
+
+// splitDoc breaks a doc comment into its first paragraph (with the lines
+// joined) and the remaining text.
+func splitDoc(comment string) (first, rest string) {
+	comment = strings.TrimSpace(comment)
+	parts := strings.SplitN(comment, "\n\n", 2)
+	first = strings.Join(strings.Fields(parts[0]), " ")
+	if len(parts) > 1 {
+		rest = strings.TrimSpace(parts[1])
+	}
+	return first, rest
+}
+
+// SourceURL enables links from the markdown documentation to the source of
+// each handler. The urlTemplate is a text/template that is given a
+// SourceLocation whose File has had the root prefix removed; for example
+//
+//	s.SourceURL(repoDir, "https://github.com/me/svc/blob/main/{{.File}}#L{{.Line}}")
+func (s *Service) SourceURL(root, urlTemplate string) *Service {
+	s.sourceRoot = root
+	s.sourceURL = template.Must(template.New("source").Parse(urlTemplate))
+	s.cache.invalidate()
+	return s
+}
+
+// SourceLink returns the URL of the given route's handler source, or the
+// empty string if no SourceURL was set or the location is unknown.
+func (s *Service) SourceLink(r Route) string {
+	if s.sourceURL == nil || r.Source == nil || r.Source.File == "" {
+		return ""
+	}
+	loc := *r.Source
+	if s.sourceRoot != "" {
+		loc.File = strings.TrimPrefix(filepath.ToSlash(loc.File), filepath.ToSlash(s.sourceRoot))
+		loc.File = strings.TrimLeft(loc.File, "/")
+	}
+	buf := &bytes.Buffer{}
+	if err := s.sourceURL.Execute(buf, loc); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// ParseHandlerDocs reads the Go source files of the package in dir and
+// returns the doc comments of all its documented functions and methods,
+// keyed the way RegisterHandlerDocs expects. importPath is the import path
+// of that package. File names are recorded relative to trimRoot, if given.
+func ParseHandlerDocs(dir, importPath, trimRoot string) (map[string]HandlerDoc, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	pkgs := make(map[string][]*ast.File)
+	for _, m := range matches {
+		if strings.HasSuffix(m, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, m, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkgs[f.Name.Name] = append(pkgs[f.Name.Name], f)
+	}
+	names := make([]string, 0, len(pkgs))
+	for name := range pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	docs := make(map[string]HandlerDoc)
+	for _, name := range names {
+		p, err := doc.NewFromFiles(fset, pkgs[name], importPath, doc.AllDecls|doc.AllMethods)
+		if err != nil {
+			return nil, err
+		}
+		add := func(key string, decl *ast.FuncDecl, comment string) {
+			if comment == "" {
+				return
+			}
+			pos := fset.Position(decl.Pos())
+			file := pos.Filename
+			if trimRoot != "" {
+				if rel, err := filepath.Rel(trimRoot, file); err == nil {
+					file = filepath.ToSlash(rel)
+				}
+			}
+			docs[key] = HandlerDoc{Doc: strings.TrimSpace(comment), File: file, Line: pos.Line}
+		}
+		for _, f := range p.Funcs {
+			add(importPath+"."+f.Name, f.Decl, f.Doc)
+		}
+		for _, t := range p.Types {
+			for _, f := range t.Funcs {
+				add(importPath+"."+f.Name, f.Decl, f.Doc)
+			}
+			for _, m := range t.Methods {
+				recv := t.Name
+				if strings.HasPrefix(m.Recv, "*") {
+					recv = fmt.Sprintf("(*%s)", t.Name)
+				}
+				add(importPath+"."+recv+"."+m.Name, m.Decl, m.Doc)
+			}
+		}
+	}
+	return docs, nil
+}
@@ -0,0 +1,457 @@
+package boneful
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TypeScriptOptions controls the output of GenerateTypeScript.
+type TypeScriptOptions struct {
+	// BaseURL is the default base URL of the generated client; it can also
+	// be set at run time through the exported defaults object.
+	BaseURL string
+}
+
+// GenerateTypeScript writes a TypeScript module for calling the service
+// from a browser or node: interfaces for the types of the Reads, Writes
+// and Returns models (reflected from the Go samples), and a fetch-based
+// function for each route, named after its Operation, whose parameters
+// follow the route's ParameterDocs. Unsuccessful responses are thrown as
+// an ApiError carrying the status and the decoded body.
+func (s *Service) GenerateTypeScript(w io.Writer, opts TypeScriptOptions) error {
+	doc := s.JSONDoc()
+	g := &tsGen{doc: doc, typeNames: make(map[string]string), used: make(map[string]bool)}
+	// what the runtime declares
+	for _, reserved := range []string{"ClientOptions", "defaults", "ApiError", "send"} {
+		g.used[reserved] = true
+	}
+	g.nameTypes()
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "// Code generated by boneful from the documentation of %s; DO NOT EDIT.\n\n", doc.Service.RootPath)
+	fmt.Fprintf(b, tsRuntime, strconv.Quote(opts.BaseURL))
+	g.interfaces(b)
+
+	for _, r := range doc.Routes {
+		name := uniqueName(lowerFirst(exportedName(r.Operation)), g.used)
+		if name == "" {
+			name = uniqueName(lowerFirst(exportedName(r.Method+" "+r.Path)), g.used)
+		}
+		g.function(b, name, r)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type tsGen struct {
+	doc       *JSONDoc
+	typeNames map[string]string
+	used      map[string]bool // every generated name, so none collide
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	words := nameWord.FindAllString(s, 1)
+	if len(words) > 0 && strings.ToUpper(words[0]) == words[0] && commonInitialisms[strings.ToLower(words[0])] {
+		return strings.ToLower(words[0]) + s[len(words[0]):]
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func (g *tsGen) nameTypes() {
+	gg := &goClientGen{doc: g.doc, typeNames: g.typeNames, used: g.used}
+	gg.nameTypes()
+}
+
+// tsType returns the TypeScript type for a schema.
+func (g *tsGen) tsType(sc *Schema, indent string) string {
+	if sc == nil {
+		return "unknown"
+	}
+	null := ""
+	if sc.Nullable {
+		null = " | null"
+	}
+	if sc.Ref != "" {
+		if name, ok := g.typeNames[sc.RefName()]; ok {
+			return name + null
+		}
+		return "unknown"
+	}
+	switch sc.Type {
+	case "string":
+		return "string" + null
+	case "integer", "number":
+		return "number" + null
+	case "boolean":
+		return "boolean" + null
+	case "array":
+		item := g.tsType(sc.Items, indent)
+		if strings.Contains(item, " ") && !strings.HasPrefix(item, "{") {
+			item = "(" + item + ")"
+		}
+		return item + "[]" + null
+	case "object":
+		if sc.Properties != nil {
+			return g.objectType(sc, indent) + null
+		}
+		if sc.AdditionalProperties != nil {
+			return "Record<string, " + g.tsType(sc.AdditionalProperties, indent) + ">" + null
+		}
+		return "Record<string, unknown>" + null
+	}
+	return "unknown"
+}
+
+func (g *tsGen) objectType(sc *Schema, indent string) string {
+	props := make([]string, 0, len(sc.Properties))
+	for p := range sc.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+	required := make(map[string]bool)
+	for _, r := range sc.Required {
+		required[r] = true
+	}
+	b := &strings.Builder{}
+	b.WriteString("{\n")
+	for _, p := range props {
+		opt := "?"
+		if required[p] {
+			opt = ""
+		}
+		fmt.Fprintf(b, "%s  %s%s: %s;\n", indent, tsPropName(p), opt, g.tsType(sc.Properties[p], indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// tsPropName quotes property names that aren't valid identifiers.
+func tsPropName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return strconv.Quote(name)
+		}
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
+
+func (g *tsGen) interfaces(b *strings.Builder) {
+	keys := make([]string, 0, len(g.typeNames))
+	for k := range g.typeNames {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return g.typeNames[keys[i]] < g.typeNames[keys[j]] })
+	for _, k := range keys {
+		sc := g.doc.Schemas[k]
+		fmt.Fprintf(b, "/** The JSON form of %s. */\n", k)
+		if sc.Type == "object" && sc.Properties != nil {
+			fmt.Fprintf(b, "export interface %s %s\n\n", g.typeNames[k], g.objectType(sc, ""))
+		} else {
+			fmt.Fprintf(b, "export type %s = %s;\n\n", g.typeNames[k], g.tsType(&Schema{Type: sc.Type,
+				Items: sc.Items, AdditionalProperties: sc.AdditionalProperties}, ""))
+		}
+	}
+}
+
+// tsParamType maps a parameter to a TypeScript type, using a union of
+// its allowable values if it has them.
+func tsParamType(p JSONParameter) string {
+	t := "string"
+	switch goParamType(p.DataType) {
+	case "int64", "float64":
+		t = "number"
+	case "bool":
+		t = "boolean"
+	}
+	if len(p.AllowableValues) > 0 {
+		values := make([]string, 0, len(p.AllowableValues))
+		for v := range p.AllowableValues {
+			switch t {
+			case "string":
+				v = strconv.Quote(v)
+			case "number":
+				if _, err := strconv.ParseFloat(v, 64); err != nil {
+					v = ""
+				}
+			case "boolean":
+				if v != "true" && v != "false" {
+					v = ""
+				}
+			}
+			if v == "" {
+				// not a literal of the type, so only the type can be given
+				values = nil
+				break
+			}
+			values = append(values, v)
+		}
+		if values != nil {
+			sort.Strings(values)
+			t = strings.Join(values, " | ")
+		}
+	}
+	if p.AllowMultiple {
+		if strings.Contains(t, " ") {
+			t = "(" + t + ")"
+		}
+		t += "[]"
+	}
+	return t
+}
+
+func (g *tsGen) function(b *strings.Builder, name string, r JSONRoute) {
+	typePrefix := exportedName(name)
+	var params []JSONParameter
+	var body *JSONParameter
+	for i, p := range r.Parameters {
+		if p.Kind == "body" {
+			body = &r.Parameters[i]
+			continue
+		}
+		params = append(params, p)
+	}
+	for _, m := range pathVariable.FindAllStringSubmatch(r.Path, -1) {
+		declared := false
+		for _, p := range params {
+			declared = declared || (p.Kind == "path" && p.Name == m[1])
+		}
+		if !declared {
+			params = append(params, JSONParameter{Name: m[1], Kind: "path", Required: true})
+		}
+	}
+
+	// the parameters, as an interface
+	if len(params) > 0 {
+		fmt.Fprintf(b, "/** Parameters of %s. */\nexport interface %sParams {\n", name, typePrefix)
+		for _, p := range params {
+			opt := "?"
+			if p.Required {
+				opt = ""
+			}
+			if p.Description != "" {
+				fmt.Fprintf(b, "  /** %s (%s) */\n", tsComment(p.Description), p.Kind)
+			}
+			fmt.Fprintf(b, "  %s%s: %s;\n", tsPropName(p.Name), opt, tsParamType(p))
+		}
+		b.WriteString("}\n\n")
+	}
+
+	// documented errors, by status code
+	var errs []JSONResponse
+	for _, resp := range r.Responses {
+		if !isSuccess(resp.Code) {
+			errs = append(errs, resp)
+		}
+	}
+	errType := "unknown"
+	if len(errs) > 0 {
+		fmt.Fprintf(b, "/** Bodies of the documented error responses of %s, by status. */\nexport interface %sErrors {\n", name, typePrefix)
+		for _, resp := range errs {
+			fmt.Fprintf(b, "  /** %s */\n  %d: %s;\n", tsComment(resp.Message), resp.Code, g.tsType(resp.ModelSchema, "  "))
+		}
+		b.WriteString("}\n\n")
+		errType = typePrefix + "Errors[keyof " + typePrefix + "Errors]"
+	}
+
+	outType := "void"
+	textOut := isTextType(r.Produces)
+	if len(r.WriteSample) > 0 || r.WriteSchema != nil {
+		outType = g.tsType(r.WriteSchema, "")
+		if textOut {
+			outType = "string"
+		}
+	}
+
+	b.WriteString("/**\n")
+	fmt.Fprintf(b, " * %s %s\n", r.Method, r.Path)
+	if r.Doc != "" {
+		b.WriteString(" *\n")
+		for _, line := range strings.Split(strings.TrimSpace(r.Doc), "\n") {
+			fmt.Fprintf(b, " * %s\n", tsComment(line))
+		}
+	}
+	if len(errs) > 0 {
+		b.WriteString(" *\n")
+		for _, resp := range errs {
+			fmt.Fprintf(b, " * @throws ApiError %d %s\n", resp.Code, tsComment(resp.Message))
+		}
+	}
+	b.WriteString(" */\n")
+	fmt.Fprintf(b, "export async function %s(", name)
+	var args []string
+	if len(params) > 0 {
+		args = append(args, "params: "+typePrefix+"Params")
+	}
+	if body != nil {
+		bt := g.tsType(r.ReadSchema, "")
+		if isTextType(r.Consumes) {
+			bt = "string"
+		}
+		args = append(args, "body: "+bt)
+	}
+	args = append(args, "options: ClientOptions = {}")
+	fmt.Fprintf(b, "%s): Promise<%s> {\n", strings.Join(args, ", "), outType)
+
+	b.WriteString("  let path = " + strconv.Quote(r.Path) + ";\n")
+	hasForm := false
+	for _, p := range params {
+		hasForm = hasForm || (p.Kind == "form" && body == nil)
+	}
+	b.WriteString("  const query = new URLSearchParams();\n  const headers: Record<string, string> = {};\n")
+	if hasForm {
+		b.WriteString("  const form = new URLSearchParams();\n")
+	}
+	for _, p := range params {
+		access := "params[" + strconv.Quote(p.Name) + "]"
+		switch p.Kind {
+		case "path":
+			fmt.Fprintf(b, "  path = path.replace(%s, encodeURIComponent(String(%s)));\n",
+				tsPathPattern(r.Path, p.Name), access)
+			continue
+		case "form":
+			if body != nil {
+				continue
+			}
+		}
+		var add string
+		switch p.Kind {
+		case "query":
+			add = "query.append(%q, String(v))"
+		case "header":
+			add = "headers[%q] = String(v)"
+		case "form":
+			add = "form.append(%q, String(v))"
+		default:
+			continue
+		}
+		add = fmt.Sprintf(add, p.Name)
+		switch {
+		case p.AllowMultiple && p.Kind == "header":
+			// a record holds one value per header, so they are joined
+			// as a list, as HTTP allows
+			fmt.Fprintf(b, "  if (%s?.length) {\n    headers[%q] = %s.map(String).join(\", \");\n  }\n", access, p.Name, access)
+		case p.AllowMultiple:
+			fmt.Fprintf(b, "  for (const v of %s ?? []) {\n    %s;\n  }\n", access, add)
+		default:
+			fmt.Fprintf(b, "  if (%s !== undefined) {\n    const v = %s;\n    %s;\n  }\n", access, access, add)
+		}
+	}
+	reqBody := "undefined"
+	switch {
+	case body != nil && isTextType(r.Consumes):
+		fmt.Fprintf(b, "  headers[\"Content-Type\"] = %q;\n", firstOr(r.Consumes, "text/plain"))
+		reqBody = "body"
+	case body != nil:
+		b.WriteString("  headers[\"Content-Type\"] = \"application/json\";\n")
+		reqBody = "JSON.stringify(body)"
+	case hasForm:
+		b.WriteString("  headers[\"Content-Type\"] = \"application/x-www-form-urlencoded\";\n")
+		reqBody = "form.toString()"
+	}
+	fmt.Fprintf(b, "  const resp = await send(%q, path, query, headers, %s, options);\n", r.Method, reqBody)
+	fmt.Fprintf(b, "  if (!resp.ok) {\n    throw await ApiError.from<%s>(resp);\n  }\n", errType)
+	switch {
+	case outType == "void":
+		b.WriteString("}\n\n")
+	case textOut:
+		b.WriteString("  return resp.text();\n}\n\n")
+	default:
+		fmt.Fprintf(b, "  return (await resp.json()) as %s;\n}\n\n", outType)
+	}
+}
+
+// tsPathPattern returns a regular expression literal matching the named
+// variable in a bone path, as a whole segment, so that ":id" doesn't
+// match the start of ":idx".
+func tsPathPattern(path, name string) string {
+	pattern := ":" + name
+	for _, seg := range strings.Split(path, "/") {
+		if m := pathVariable.FindStringSubmatch(seg); m != nil && m[1] == name && strings.IndexAny(seg, ":#") == 0 {
+			pattern = seg
+			break
+		}
+	}
+	return "/" + tsRegexpEscaper.Replace(pattern) + `(?=\/|$)/`
+}
+
+// tsRegexpEscaper escapes text for a JavaScript regular expression literal.
+var tsRegexpEscaper = strings.NewReplacer(
+	`\`, `\\`, `/`, `\/`, `^`, `\^`, `$`, `\$`, `.`, `\.`, `|`, `\|`, `?`, `\?`,
+	`*`, `\*`, `+`, `\+`, `(`, `\(`, `)`, `\)`, `[`, `\[`, `]`, `\]`, `{`, `\{`, `}`, `\}`,
+)
+
+// tsComment keeps text from ending a block comment.
+func tsComment(s string) string {
+	return strings.Replace(strings.Replace(s, "*/", "*\\/", -1), "\n", " ", -1)
+}
+
+var tsRuntime = `/** Options for each call; anything omitted comes from defaults. */
+export interface ClientOptions {
+  /** The scheme, host and any path prefix of the service. */
+  baseUrl?: string;
+  /** Headers added to every request, such as authorization. */
+  headers?: Record<string, string>;
+  /** The fetch implementation to use. */
+  fetch?: typeof fetch;
+  /** Passed along to fetch, for cancellation. */
+  signal?: AbortSignal;
+}
+
+/** Defaults for every call; change them to configure the client. */
+export const defaults: ClientOptions = {
+  baseUrl: %s,
+  headers: {},
+};
+
+/** Thrown for unsuccessful responses; body is the decoded response. */
+export class ApiError<T = unknown> extends Error {
+  constructor(
+    public readonly status: number,
+    public readonly body: T,
+    public readonly response: Response,
+  ) {
+    super(` + "`${status} ${response.statusText}`" + `);
+    this.name = "ApiError";
+  }
+
+  static async from<T>(resp: Response): Promise<ApiError<T>> {
+    const text = await resp.text();
+    let body: unknown = text;
+    try {
+      body = JSON.parse(text);
+    } catch {
+      // not JSON; keep the text
+    }
+    return new ApiError<T>(resp.status, body as T, resp);
+  }
+}
+
+async function send(
+  method: string,
+  path: string,
+  query: URLSearchParams,
+  headers: Record<string, string>,
+  body: BodyInit | undefined,
+  options: ClientOptions,
+): Promise<Response> {
+  const base = (options.baseUrl ?? defaults.baseUrl ?? "").replace(/\/+$/, "");
+  const qs = query.toString();
+  const doFetch = options.fetch ?? defaults.fetch ?? fetch;
+  return doFetch(base + path + (qs ? "?" + qs : ""), {
+    method,
+    headers: { ...defaults.headers, ...options.headers, ...headers },
+    body,
+    signal: options.signal,
+  });
+}
+
+`
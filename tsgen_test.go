@@ -0,0 +1,39 @@
+package boneful
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	s := edgeCaseService()
+	buf := &bytes.Buffer{}
+	assert.Nil(t, s.GenerateTypeScript(buf, TypeScriptOptions{BaseURL: "http://localhost:8080"}))
+	ts := buf.String()
+	assert.Contains(t, ts, "export interface Node {\n  children?: (Node | null)[];\n  name: string;\n}")
+	assert.Contains(t, ts, "export interface AddParams {\n  /** Tracing id (header) */\n  \"X-Trace\"?: string;\n")
+	assert.Contains(t, ts, "  color?: \"blue\" | \"red\";\n")
+	assert.Contains(t, ts, "  if (params[\"X-Tag\"]?.length) {\n    headers[\"X-Tag\"] = params[\"X-Tag\"].map(String).join(\", \");\n  }\n")
+	assert.Contains(t, ts, "  limit: number;\n")
+	assert.Contains(t, ts, "export interface AddErrors {\n  /** bad */\n  400: unknown;\n  /** exists */\n  409: Widget;\n}")
+	assert.Contains(t, ts, "export async function add(params: AddParams, body: Node, options: ClientOptions = {}): Promise<Widget[]> {")
+	assert.Contains(t, ts, "export async function getWidgetByID(params: GetWidgetByIDParams, options: ClientOptions = {}): Promise<Widget> {")
+	assert.Contains(t, ts, `path = path.replace(/:id(?=\/|$)/, encodeURIComponent(String(params["id"])));`)
+	assert.Contains(t, ts, "export async function getWidgetByID2(params: GetWidgetByID2Params, body: Widget, options: ClientOptions = {}): Promise<string> {")
+	assert.Contains(t, ts, `baseUrl: "http://localhost:8080",`)
+}
+
+func TestTypeScriptParams(t *testing.T) {
+	assert.Equal(t, `/:id(?=\/|$)/`, tsPathPattern("/a/:idx/:id", "id"))
+	assert.Equal(t, `/:idx(?=\/|$)/`, tsPathPattern("/a/:idx/:id", "idx"))
+	assert.Equal(t, `/#id\^\[0-9\]\+\$(?=\/|$)/`, tsPathPattern("/a/#id^[0-9]+$", "id"))
+
+	number := JSONParameter{Name: "n", DataType: "integer", AllowableValues: map[string]string{"1": "", "2": ""}}
+	assert.Equal(t, "1 | 2", tsParamType(number))
+	number.AllowableValues["many"] = ""
+	assert.Equal(t, "number", tsParamType(number))
+	flag := JSONParameter{Name: "f", DataType: "boolean", AllowableValues: map[string]string{"yes": ""}}
+	assert.Equal(t, "boolean", tsParamType(flag))
+}
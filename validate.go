@@ -0,0 +1,151 @@
+package boneful
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Validate checks a JSON document against the schema, looking up refs in
+// defs, and describes each place where the document doesn't conform. As
+// in JSON Schema, properties that the schema doesn't mention are allowed.
+// Since encoding/json writes nil slices and maps as null, null is accepted
+// for arrays and maps even if they aren't Nullable.
+func (sc *Schema) Validate(data []byte, defs map[string]*Schema) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return []string{fmt.Sprintf("$: invalid JSON: %v", err)}
+	}
+	var problems []string
+	sc.validate(v, defs, "$", &problems)
+	return problems
+}
+
+func (sc *Schema) validate(v interface{}, defs map[string]*Schema, path string, problems *[]string) {
+	if sc == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+	if sc.Ref != "" {
+		if v == nil {
+			if !sc.Nullable {
+				fail("null, want %s", sc.RefName())
+			}
+			return
+		}
+		if def, ok := defs[sc.RefName()]; ok {
+			def.validate(v, defs, path, problems)
+		}
+		return
+	}
+	if v == nil {
+		if !sc.Nullable && sc.Type != "" && sc.Type != "array" && sc.Type != "object" {
+			fail("null, want %s", sc.Type)
+		}
+		return
+	}
+
+	if len(sc.Enum) > 0 {
+		if s, ok := enumValue(v); ok && !inEnum(sc.Enum, s) {
+			fail("%q is not one of %s", s, strings.Join(sc.Enum, ", "))
+		}
+	}
+
+	switch sc.Type {
+	case "string":
+		if _, ok := v.(string); !ok {
+			fail("%s, want string", jsonKind(v))
+		}
+	case "integer":
+		if n, ok := v.(json.Number); !ok || strings.ContainsAny(string(n), ".eE") {
+			fail("%s, want integer", jsonKind(v))
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			fail("%s, want number", jsonKind(v))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("%s, want boolean", jsonKind(v))
+		}
+	case "array":
+		list, ok := v.([]interface{})
+		if !ok {
+			fail("%s, want array", jsonKind(v))
+			return
+		}
+		for i, item := range list {
+			sc.Items.validate(item, defs, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			fail("%s, want object", jsonKind(v))
+			return
+		}
+		for _, name := range sc.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := sc.Properties[k]; ok {
+				ps.validate(obj[k], defs, path+"."+k, problems)
+			} else if sc.AdditionalProperties != nil {
+				sc.AdditionalProperties.validate(obj[k], defs, path+"."+k, problems)
+			}
+		}
+	}
+}
+
+// jsonKind names the JSON type of a decoded value, for messages.
+func jsonKind(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// enumValue is the text of a string or number, to compare with an Enum.
+func enumValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return string(v), true
+	}
+	return "", false
+}
+
+func inEnum(enum []string, s string) bool {
+	for _, e := range enum {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
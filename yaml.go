@@ -0,0 +1,122 @@
+package boneful
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonToYAML re-encodes a JSON document as YAML, so that the YAML form of
+// the documentation always has the same shape (and field names) as the
+// JSON form. Object keys are written in sorted order.
+func jsonToYAML(w io.Writer, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		writeYAML(buf, v, 0)
+	default:
+		buf.WriteString(yamlScalar(v) + "\n")
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			buf.WriteString(pad + "{}\n")
+			return
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			buf.WriteString(pad + yamlString(k) + ":")
+			writeYAMLValue(buf, t[k], indent+1)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			buf.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range t {
+			// maps start on the same line as their "-"
+			if m, ok := item.(map[string]interface{}); ok && len(m) > 0 {
+				sub := &bytes.Buffer{}
+				writeYAML(sub, m, indent+1)
+				buf.WriteString(pad + "- ")
+				buf.Write(sub.Bytes()[len(pad)+2:])
+				continue
+			}
+			buf.WriteString(pad + "-")
+			writeYAMLValue(buf, item, indent+1)
+		}
+	}
+}
+
+// writeYAMLValue writes what follows a "key:" or "-", either inline or as
+// an indented block.
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+	default:
+		buf.WriteString(" " + yamlScalar(v) + "\n")
+		return
+	}
+	buf.WriteString("\n")
+	writeYAML(buf, v, indent)
+}
+
+func yamlScalar(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(t)
+	case json.Number:
+		return t.String()
+	case string:
+		return yamlString(t)
+	default:
+		return yamlString(fmt.Sprint(t))
+	}
+}
+
+var (
+	yamlPlain    = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./()+-]*( [A-Za-z0-9_./()+-]+)*$`)
+	yamlReserved = regexp.MustCompile(`^(?i:y|n|yes|no|on|off|true|false|null|~)$`)
+)
+
+// yamlString writes s unquoted when that is unambiguous, and as a
+// double-quoted (JSON-compatible) string otherwise.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !yamlReserved.MatchString(s) {
+		return s
+	}
+	b, _ := json.Marshal(s)
+	return string(b)
+}